@@ -0,0 +1,105 @@
+package pass
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+// The prefix of hashes bound to an owner, followed by the hash
+// of the bound password in the format of its scheme.
+const boundPrefix = "{BOUND}"
+
+// Binding controls whether hashes are bound to their owner,
+// such as a user ID, so that a hash copied from one account to
+// another does not verify. The owner is mixed into the password
+// with HMAC-SHA256 before it is hashed by any scheme, and bound
+// hashes are marked with a "{BOUND}" prefix. This includes argon2,
+// as golang.org/x/crypto/argon2 does not support associated data.
+//
+// Binding protects against hashes being swapped between accounts,
+// not against attackers who can compute new hashes themselves.
+type Binding int
+
+const (
+	// Hashes are not bound. Existing bound hashes can still be
+	// verified with VerifyBound.
+	BindNone Binding = iota
+	// New hashes are bound. Unbound hashes are accepted by VerifyBound
+	// and upgraded, and NeedsUpdate reports them, to migrate existing hashes.
+	BindUpgrade
+	// Hashes must be bound. VerifyBound rejects unbound hashes
+	// with ErrUnbound, as they could have been copied from another account.
+	BindRequire
+)
+
+var (
+	// Returned when hashing or verifying without an owner,
+	// either because the context binds hashes or the hash is bound.
+	ErrOwnerRequired = errors.New("an owner is required for bound hashes")
+
+	// Returned by VerifyBound for hashes which are not bound to an owner
+	// if the context requires it. It is of kind scheme.ErrPolicyRejected.
+	ErrUnbound error = scheme.Rejected("", "owner", errors.New("hash is not bound to an owner"))
+)
+
+// Like Hash, but binds the hash to owner, which must not be empty.
+// See Binding.
+func (ctx *Context) HashBound(owner, password string) (hash string, err error) {
+	if owner == "" {
+		return "", ErrOwnerRequired
+	}
+
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return ctx.hash(b, []byte(owner))
+}
+
+// Like Verify, but verifies that the hash is bound to owner,
+// which must not be empty. Unbound hashes are accepted and upgraded
+// unless the context uses BindRequire.
+// See Binding.
+func (ctx *Context) VerifyBound(owner, password, hash string) (newHash string, err error) {
+	if owner == "" {
+		return "", ErrOwnerRequired
+	}
+
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	res, err := ctx.verify(b, hash, []byte(owner), true)
+	if err != nil {
+		return "", err
+	}
+
+	return res.NewHash, nil
+}
+
+// bind derives the password hashed by the schemes for a bound hash.
+// The result is base64-encoded, as some schemes stop at NUL bytes,
+// and should be zeroed by the caller.
+func bind(owner, password []byte) []byte {
+	mac := hmac.New(sha256.New, owner)
+	mac.Write(password)
+	sum := mac.Sum(nil)
+	defer scheme.Zero(sum)
+
+	key := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+	base64.StdEncoding.Encode(key, sum)
+
+	return key
+}
+
+// unbind returns the hash of the bound password and whether hash is bound.
+func unbind(hash string) (string, bool) {
+	if !strings.HasPrefix(hash, boundPrefix) {
+		return hash, false
+	}
+
+	return hash[len(boundPrefix):], true
+}
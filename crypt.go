@@ -0,0 +1,290 @@
+package pass
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	argon2raw "github.com/pchchv/pass/hash/argon2/raw"
+	"github.com/pchchv/pass/hash/bcrypt"
+	bcryptraw "github.com/pchchv/pass/hash/bcrypt/raw"
+	"github.com/pchchv/pass/hash/phpass"
+	scryptraw "github.com/pchchv/pass/hash/scrypt/raw"
+	sha2raw "github.com/pchchv/pass/hash/sha2/raw"
+	"github.com/pchchv/pass/scheme"
+	"golang.org/x/crypto/argon2"
+)
+
+// CryptFunc computes the hash of a password using the parameters
+// and salt of setting, which may also be a complete hash.
+type CryptFunc func(password []byte, setting string) (string, error)
+
+// GensaltFunc returns a setting for CryptFunc with the given cost,
+// reading the salt from r. A cost of zero selects the default cost.
+type GensaltFunc func(cost int, r io.Reader) (string, error)
+
+type cryptEntry struct {
+	crypt   CryptFunc
+	gensalt GensaltFunc
+}
+
+var (
+	cryptMu       sync.RWMutex
+	cryptRegistry = map[string]cryptEntry{}
+)
+
+// RegisterCrypt registers the functions used by Crypt and CryptGensalt
+// for settings starting with prefix, such as "$6$".
+// The longest registered prefix of a setting is used.
+// Registering a prefix again replaces the previous functions.
+// gensalt may be nil for formats which Crypt can only verify.
+func RegisterCrypt(prefix string, crypt CryptFunc, gensalt GensaltFunc) {
+	cryptMu.Lock()
+	defer cryptMu.Unlock()
+
+	cryptRegistry[prefix] = cryptEntry{crypt, gensalt}
+}
+
+func init() {
+	// As in libxcrypt, $2$ hashes can be verified but not generated.
+	RegisterCrypt("$2$", cryptBcrypt, nil)
+	for _, variant := range []string{"2a", "2b", "2x", "2y"} {
+		RegisterCrypt("$"+variant+"$", cryptBcrypt, gensaltBcrypt(variant))
+	}
+
+	RegisterCrypt("$5$", cryptSHA2, gensaltSHA2("$5$"))
+	RegisterCrypt("$6$", cryptSHA2, gensaltSHA2("$6$"))
+	RegisterCrypt("$P$", phpass.CryptBytes, gensaltPHPass)
+	RegisterCrypt("$H$", phpass.CryptBytes, nil)
+	RegisterCrypt("$argon2i$", cryptArgon2, gensaltArgon2)
+	RegisterCrypt("$s2$", cryptScrypt, gensaltScrypt)
+}
+
+// Crypt hashes password using the scheme, parameters and salt of setting,
+// like the crypt(3) function of the C library.
+// setting is usually the result of CryptGensalt or an existing hash,
+// in which case the result equals the hash if the password is correct.
+// For the formats supported by glibc and libxcrypt ($2a$, $2b$, $2x$, $2y$,
+// $5$ and $6$) the result is the same as theirs. sha-crypt settings are
+// parsed as by glibc: the salt ends at the first "$" after it, out-of-range
+// rounds are clamped and a "rounds=" field not followed by a number and "$"
+// is part of the salt, where libxcrypt fails instead.
+// Where the C library returns a failure token such as "*0",
+// Crypt returns an error.
+//
+// Crypt does not enforce any limits on the parameters of setting,
+// so settings from untrusted sources should be checked first.
+// Use a Context to verify stored hashes.
+func Crypt(password, setting string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return CryptBytes(b, setting)
+}
+
+// Like Crypt, but takes the password as a byte slice.
+func CryptBytes(password []byte, setting string) (string, error) {
+	e, ok := lookupCrypt(setting)
+	if !ok {
+		return "", scheme.ErrUnsupportedScheme
+	}
+
+	return e.crypt(password, setting)
+}
+
+// CryptGensalt returns a setting for Crypt for the scheme identified by prefix,
+// like crypt_gensalt(3) from libxcrypt. The meaning of cost depends on the scheme:
+//
+//	$2a$, $2b$, $2x$, $2y$   bcrypt cost (base 2 logarithm), default bcrypt.RecommendedCost
+//	$5$, $6$                 rounds, default 5000 (omitted from the setting)
+//	$P$                      base 2 logarithm of the rounds, default phpass.RecommendedRounds
+//	$argon2i$                time, with the recommended memory and threads
+//	$s2$                     base 2 logarithm of N, with the recommended r and p
+//
+// The salt is read from r, or from crypto/rand.Reader if r is nil.
+func CryptGensalt(prefix string, cost int, r io.Reader) (string, error) {
+	cryptMu.RLock()
+	e, ok := cryptRegistry[prefix]
+	cryptMu.RUnlock()
+
+	if !ok || e.gensalt == nil {
+		return "", scheme.ErrUnsupportedScheme
+	}
+
+	return e.gensalt(cost, r)
+}
+
+// lookupCrypt returns the entry of the longest registered prefix of setting.
+func lookupCrypt(setting string) (cryptEntry, bool) {
+	cryptMu.RLock()
+	defer cryptMu.RUnlock()
+
+	prefixes := make([]string, 0, len(cryptRegistry))
+	for prefix := range cryptRegistry {
+		if strings.HasPrefix(setting, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if len(prefixes) == 0 {
+		return cryptEntry{}, false
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	return cryptRegistry[prefixes[0]], true
+}
+
+func cryptBcrypt(password []byte, setting string) (string, error) {
+	variant, cost, salt, _, err := bcryptraw.Parse(setting)
+	if err != nil {
+		return "", err
+	}
+
+	return bcryptraw.CryptBytes(password, salt, cost, variant)
+}
+
+func gensaltBcrypt(variant string) GensaltFunc {
+	return func(cost int, r io.Reader) (string, error) {
+		if cost == 0 {
+			cost = bcrypt.RecommendedCost
+		}
+
+		if cost < bcryptraw.MinCost || cost > bcryptraw.MaxCost {
+			return "", bcryptraw.ErrInvalidCost
+		}
+
+		salt := make([]byte, 16)
+		if err := scheme.ReadSalt("bcrypt", r, salt); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("$%s$%02d$%s", variant, cost, bcryptraw.EncodeSalt(salt)), nil
+	}
+}
+
+func cryptSHA2(password []byte, setting string) (string, error) {
+	isSHA512, salt, rounds, explicit, err := sha2raw.ParseSetting(setting)
+	if err != nil {
+		return "", err
+	}
+
+	var h string
+	if isSHA512 {
+		h, err = sha2raw.Crypt512Bytes(password, salt, rounds)
+	} else {
+		h, err = sha2raw.Crypt256Bytes(password, salt, rounds)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// The C library keeps explicit default rounds in the output.
+	if rounds == sha2raw.DefaultRounds && explicit {
+		h = fmt.Sprintf("%srounds=%d$%s", h[:3], rounds, h[3:])
+	}
+
+	return h, nil
+}
+
+func gensaltSHA2(prefix string) GensaltFunc {
+	return func(cost int, r io.Reader) (string, error) {
+		if cost != 0 && (cost < sha2raw.MinimumRounds || cost > sha2raw.MaximumRounds) {
+			return "", sha2raw.ErrInvalidRounds
+		}
+
+		salt := make([]byte, 12)
+		if err := scheme.ReadSalt("sha-crypt", r, salt); err != nil {
+			return "", err
+		}
+
+		encoded := sha2raw.EncodeBase64(salt)[:sha2raw.MaxSaltLength]
+		if cost == 0 {
+			return prefix + encoded, nil
+		}
+
+		return fmt.Sprintf("%srounds=%d$%s", prefix, cost, encoded), nil
+	}
+}
+
+func gensaltPHPass(cost int, r io.Reader) (string, error) {
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	if cost == 0 {
+		cost = phpass.RecommendedRounds
+	}
+
+	if cost < phpass.MinRounds || cost > phpass.MaxRounds {
+		return "", phpass.ErrInvalidRounds
+	}
+
+	salt := make([]byte, 8)
+	if err := scheme.ReadSalt("phpass", r, salt); err != nil {
+		return "", err
+	}
+
+	for i := range salt {
+		salt[i] = itoa64[salt[i]&0x3F]
+	}
+
+	return "$P$" + string(itoa64[cost]) + string(salt), nil
+}
+
+func cryptArgon2(password []byte, setting string) (string, error) {
+	salt, _, _, time, memory, threads, err := argon2raw.Parse(setting)
+	if err != nil {
+		return "", err
+	}
+
+	return argon2raw.Argon2Bytes(password, salt, time, memory, threads)
+}
+
+func gensaltArgon2(cost int, r io.Reader) (string, error) {
+	if cost == 0 {
+		cost = int(argon2raw.RecommendedTime)
+	}
+
+	if cost < 1 || uint64(cost) > math.MaxUint32 {
+		return "", argon2raw.ErrInvalidTime
+	}
+
+	salt := make([]byte, 16)
+	if err := scheme.ReadSalt("argon2", r, salt); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$argon2i$v=%d$m=%d,t=%d,p=%d$%s", argon2.Version,
+		argon2raw.RecommendedMemory, cost, argon2raw.RecommendedThreads, base64.RawStdEncoding.EncodeToString(salt)), nil
+}
+
+func cryptScrypt(password []byte, setting string) (string, error) {
+	salt, _, N, r, p, err := scryptraw.Parse(setting)
+	if err != nil {
+		return "", err
+	}
+
+	return scryptraw.ScryptSHA256Bytes(password, salt, N, r, p)
+}
+
+func gensaltScrypt(cost int, r io.Reader) (string, error) {
+	N := scryptraw.RecommendedN
+	if cost != 0 {
+		if cost < 1 || cost > 30 {
+			return "", scryptraw.ErrInvalidParams
+		}
+
+		N = 1 << cost
+	}
+
+	salt := make([]byte, 18)
+	if err := scheme.ReadSalt("scrypt", r, salt); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("$s2$%d$%d$%d$%s", N, scryptraw.Recommendedr, scryptraw.Recommendedp, base64.StdEncoding.EncodeToString(salt)), nil
+}
@@ -0,0 +1,115 @@
+package pass
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	sha2raw "github.com/pchchv/pass/hash/sha2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestCrypt(t *testing.T) {
+	// Produced by crypt(3) from libxcrypt.
+	for _, test := range []struct {
+		password, setting, hash string
+	}{
+		{"Hello world!", "$5$saltstring", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"},
+		{"Hello world!", "$5$rounds=5000$saltstring", "$5$rounds=5000$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"},
+		{"Hello world!", "$6$rounds=1000$toolongsaltstring12345", "$6$rounds=1000$toolongsaltstrin$sesQxVr.eO8J/1tYcwFWM0XaMVaHFLetz9ssK1oNSPUQgLRm8v3S4i6CgxB9aqGOAFFEMnB2V1gvxEm/Gv2gw/"},
+		{"Hello world!", "$6$salt$ignoredhash", "$6$salt$F6mPVWtXQWa41OpigvMj06czJyMjPRUJ7/KTt0mPaEFiA1BVVgXkqZO9lAnZwubnctt3dL6saXIFdviUxvcS4."},
+		{"Hello world!", "$5$", "$5$$mAwMsDaqjtxAtGqstEIf7OBR15rgcx.jSKGM94IKRj/"},
+		{"Hello world!", "$2b$04$abcdefghijklmnopqrstuu", "$2b$04$abcdefghijklmnopqrstuuyeG8laUfZvsCmc.AE6qIDYSPGM2efmK"},
+		// The unused bits of the last salt character are cleared.
+		{"Hello world!", "$2b$04$abcdefghijklmnopqrstuv", "$2b$04$abcdefghijklmnopqrstuuyeG8laUfZvsCmc.AE6qIDYSPGM2efmK"},
+		{"Hello world!", "$2a$04$abcdefghijklmnopqrstuu", "$2a$04$abcdefghijklmnopqrstuuyeG8laUfZvsCmc.AE6qIDYSPGM2efmK"},
+		{"Hello world!", "$2y$05$abcdefghijklmnopqrstuu", "$2y$05$abcdefghijklmnopqrstuu7nFISH/8YdwlXD3lw69A4iBUf6fvWAW"},
+		{"\xff\xa3", "$2x$04$abcdefghijklmnopqrstuu", "$2x$04$abcdefghijklmnopqrstuuTK1X4nGg.aXsXLnUUyd5HSO2n7nnHee"},
+		// Everything after the salt is ignored.
+		{"password", "$6$rounds=1000$a$b$c", "$6$rounds=1000$a$Kxk3PPQDSVhO/cLNlccYZv3gI.T20HjR4w.Yb3g3ss1RBQhdeiSYn9RZwrrKer79EBx71xCNfQfT4Auef4uZr0"},
+	} {
+		h, err := Crypt(test.password, test.setting)
+		if err != nil || h != test.hash {
+			t.Errorf("Crypt(%q, %q) = %q, %v, expected %q", test.password, test.setting, h, err, test.hash)
+		}
+
+		// Hashing with the hash as setting reproduces it.
+		if h, err := Crypt(test.password, test.hash); err != nil || h != test.hash {
+			t.Errorf("Crypt(%q, %q) = %q, %v", test.password, test.hash, h, err)
+		}
+	}
+
+	// glibc treats a rounds field without a number and "$" as part of
+	// the salt, with the default rounds; libxcrypt rejects such settings.
+	for _, salt := range []string{"rounds=1000abc", "rounds=1000"} {
+		expected, err := sha2raw.Crypt256("password", salt, sha2raw.DefaultRounds)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if h, err := Crypt("password", "$5$"+salt); err != nil || h != expected {
+			t.Errorf("Crypt with setting %q = %q, %v, expected %q", "$5$"+salt, h, err, expected)
+		}
+	}
+
+	// An empty number is zero rounds, which are clamped.
+	if h, err := Crypt("password", "$5$rounds=$salt"); err != nil || !strings.HasPrefix(h, "$5$rounds=1000$salt$") {
+		t.Errorf("Crypt with empty rounds = %q, %v", h, err)
+	}
+
+	for _, setting := range []string{"", "$1$salt", "$2b$4$abcdefghijklmnopqrstuu", "$7$CU..../....abcd"} {
+		if h, err := Crypt("password", setting); err == nil {
+			t.Errorf("Crypt with setting %q succeeded: %q", setting, h)
+		}
+	}
+}
+
+func TestCryptGensalt(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		cost   int
+		start  string
+	}{
+		{"$2b$", 4, "$2b$04$"},
+		{"$2y$", 0, "$2y$12$"},
+		{"$5$", 0, "$5$"},
+		{"$6$", 1000, "$6$rounds=1000$"},
+		{"$P$", 7, "$P$5"},
+		{"$argon2i$", 1, "$argon2i$v=19$m=32768,t=1,p=4$"},
+		{"$s2$", 4, "$s2$16$8$1$"},
+	} {
+		setting, err := CryptGensalt(test.prefix, test.cost, constReader(7))
+		if err != nil || !strings.HasPrefix(setting, test.start) || strings.IndexFunc(setting, func(r rune) bool { return r <= ' ' || r > '~' }) >= 0 {
+			t.Errorf("CryptGensalt(%q, %d) = %q, %v", test.prefix, test.cost, setting, err)
+			continue
+		}
+
+		h, err := Crypt("password", setting)
+		if err != nil {
+			t.Errorf("Crypt with setting %q: %v", setting, err)
+			continue
+		}
+
+		if h2, err := Crypt("password", h); err != nil || h2 != h {
+			t.Errorf("Crypt(%q) = %q, %v, expected %q", h, h2, err, h)
+		}
+
+		if h2, err := Crypt("wrong", h); err != nil || h2 == h {
+			t.Errorf("Crypt(%q) with wrong password = %q, %v", h, h2, err)
+		}
+	}
+
+	if _, err := CryptGensalt("$2b$", 3, nil); err == nil {
+		t.Errorf("invalid cost accepted")
+	}
+
+	for _, prefix := range []string{"$1$", "$2$", "$H$"} {
+		if _, err := CryptGensalt(prefix, 0, nil); err != scheme.ErrUnsupportedScheme {
+			t.Errorf("%s: expected ErrUnsupportedScheme, got %v", prefix, err)
+		}
+	}
+
+	if _, err := CryptGensalt("$6$", 0, strings.NewReader("")); !errors.Is(err, scheme.ErrEntropyFailure) {
+		t.Errorf("expected entropy failure, got %v", err)
+	}
+}
@@ -24,6 +24,9 @@ var (
 	// The DefaultSchemes value will not change.
 	// You need to call UseDefaults to allow your application to switch to newer hash schemes
 	// (either set DefaultSchemes manually, or create a custom context with its own set of schemes).
+	//
+	// DefaultSchemes must not be changed while passwords are being hashed or verified.
+	// To reconfigure at runtime, install a context with its own schemes using SetDefault.
 	DefaultSchemes []scheme.Scheme
 
 	defaultSchemes = []scheme.Scheme{
@@ -0,0 +1,45 @@
+package pass
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/hash/atlassian"
+	"github.com/pchchv/pass/hash/cisco"
+	"github.com/pchchv/pass/hash/grub"
+	"github.com/pchchv/pass/hash/ldap"
+	"github.com/pchchv/pass/hash/phpass"
+	"github.com/pchchv/pass/scheme"
+)
+
+func FuzzVerify(f *testing.F) {
+	f.Add("password", "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$")
+	f.Add("password", "$s2$16$1$1$c2FsdA==$")
+	f.Add("password", "$2b$04$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")
+	f.Add("password", "$bcrypt-sha256$v=2,t=2b,r=4$n79VH.0Q2TMWmt3Oqt9uku$")
+	f.Add("password", "$pbkdf2-sha256$1000$c2FsdA$")
+	f.Add("password", "$5$rounds=1000$salt$")
+	f.Add("password", "{SSHA}MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=")
+	f.Add("password", "$P$7IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0")
+	f.Add("password", "grub.pbkdf2.sha512.1000.00.00")
+	f.Add("password", "$8$")
+	f.Add("password", "{PKCS5S2}")
+
+	c := Context{Schemes: append(append([]scheme.Scheme{}, DefaultSchemes...),
+		ldap.SaltedSHA1Crypter,
+		ldap.CryptCrypter,
+		phpass.Crypter,
+		grub.Crypter,
+		atlassian.Crypter,
+		cisco.Type8Crypter,
+	)}
+
+	// Keep the fuzzer fast.
+	c.Limits = &scheme.Limits{MaxRounds: 5000, MaxLogRounds: 6, MaxMemory: 1 << 20, MaxPasses: 2}
+
+	f.Fuzz(func(t *testing.T, password, hash string) {
+		res, err := c.VerifyDetailed(password, hash)
+		if err == nil && res == nil {
+			t.Errorf("nil result without error for %q", hash)
+		}
+	})
+}
@@ -3,9 +3,10 @@
 package argon2
 
 import (
-	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/pass/hash/argon2/raw"
@@ -20,38 +21,95 @@ const saltLength = 16
 var Crypter scheme.Scheme
 
 func init() {
-	Crypter = New(
-		raw.RecommendedTime,
-		raw.RecommendedMemory,
-		raw.RecommendedThreads,
-	)
+	Crypter = NewWithOptions()
 }
 
 type argon2Scheme struct {
 	time, memory uint32
 	threads      uint8
+	rand         io.Reader
+	ceilings     *scheme.Limits
+}
+
+// Option configures a scheme created by NewWithOptions.
+type Option func(*argon2Scheme)
+
+// WithTime sets the number of passes over the memory.
+func WithTime(time uint32) Option {
+	return func(c *argon2Scheme) { c.time = time }
+}
+
+// WithMemory sets the memory size in KiB.
+func WithMemory(memory uint32) Option {
+	return func(c *argon2Scheme) { c.memory = memory }
+}
+
+// WithThreads sets the degree of parallelism.
+func WithThreads(threads uint8) Option {
+	return func(c *argon2Scheme) { c.threads = threads }
+}
+
+// WithRand sets the source of randomness for salts.
+// If not set, crypto/rand.Reader is used.
+func WithRand(r io.Reader) Option {
+	return func(c *argon2Scheme) { c.rand = r }
+}
+
+// WithLimits sets the ceilings enforced when verifying a hash.
+// If not set, scheme.DefaultLimits is used.
+// They are raised as needed to verify the hashes the scheme produces.
+func WithLimits(limits scheme.Limits) Option {
+	return func(c *argon2Scheme) { c.ceilings = &limits }
 }
 
 // Returns an implementation of Scheme implementing argon2 with the specified parameters.
 func New(time, memory uint32, threads uint8) scheme.Scheme {
-	return &argon2Scheme{
-		time:    time,
-		memory:  memory,
-		threads: threads,
-	}
+	return NewWithOptions(WithTime(time), WithMemory(memory), WithThreads(threads))
 }
 
-func (c *argon2Scheme) SetParams(time, memory uint32, threads uint8) {
-	c.time = time
-	c.memory = memory
-	c.threads = threads
+// NewWithOptions returns an implementation of Scheme implementing argon2.
+// Parameters which are not set by an option use the recommended values from raw.
+// The returned scheme is immutable and safe for concurrent use.
+func NewWithOptions(opts ...Option) scheme.Scheme {
+	c := &argon2Scheme{
+		time:    raw.RecommendedTime,
+		memory:  raw.RecommendedMemory,
+		threads: raw.RecommendedThreads,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *argon2Scheme) SupportsStub(stub string) bool {
 	return strings.HasPrefix(stub, "$argon2i$")
 }
 
+func (c *argon2Scheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *c
+	cc.rand = r
+
+	return &cc
+}
+
+func (c *argon2Scheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *c
+	cc.ceilings = &limits
+
+	return &cc
+}
+
 func (c *argon2Scheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.HashBytes(b)
+}
+
+func (c *argon2Scheme) HashBytes(password []byte) (string, error) {
 	stub, err := c.makeStub()
 	if err != nil {
 		return "", err
@@ -61,7 +119,14 @@ func (c *argon2Scheme) Hash(password string) (string, error) {
 	return newHash, err
 }
 
-func (c *argon2Scheme) Verify(password, hash string) (err error) {
+func (c *argon2Scheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.VerifyBytes(b, hash)
+}
+
+func (c *argon2Scheme) VerifyBytes(password []byte, hash string) (err error) {
 	_, newHash, _, _, _, _, _, err := c.hash(password, hash)
 	if err == nil && !scheme.SecureCompare(hash, newHash) {
 		err = scheme.ErrInvalidPassword
@@ -70,36 +135,104 @@ func (c *argon2Scheme) Verify(password, hash string) (err error) {
 	return
 }
 
+func (c *argon2Scheme) CheckLimits(stub string, limits scheme.Limits) error {
+	_, _, _, time, memory, _, err := raw.Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return checkLimits(time, memory, limits)
+}
+
+// limits returns the configured or default limits, raised to allow the configured parameters.
+func (c *argon2Scheme) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if c.ceilings != nil {
+		limits = *c.ceilings
+	}
+
+	if m := uint64(c.memory) * 1024; limits.MaxMemory != 0 && m > limits.MaxMemory {
+		limits.MaxMemory = m
+	}
+
+	if limits.MaxPasses != 0 && int(c.time) > limits.MaxPasses {
+		limits.MaxPasses = int(c.time)
+	}
+
+	return limits
+}
+
+// checkLimits checks the time and memory parameters,
+// where memory is given in KiB as in the argon2 encoding.
+func checkLimits(time, memory uint32, limits scheme.Limits) error {
+	if err := limits.CheckMemory("argon2", "m", uint64(memory), 1024); err != nil {
+		return err
+	}
+
+	return limits.CheckPasses("argon2", "t", int(time))
+}
+
 func (c *argon2Scheme) String() string {
 	return fmt.Sprintf("argon2(%d,%d,%d,%d)", argon2.Version, c.memory, c.time, c.threads)
 }
 
 func (c *argon2Scheme) NeedsUpdate(stub string) bool {
+	return len(c.UpdateReasons(stub)) != 0
+}
+
+func (c *argon2Scheme) Params(stub string) (map[string]string, error) {
 	salt, _, version, time, memory, threads, err := raw.Parse(stub)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return c.needsUpdate(salt, version, time, memory, threads)
+	return map[string]string{
+		"v":           strconv.Itoa(version),
+		"m":           strconv.FormatUint(uint64(memory), 10),
+		"t":           strconv.FormatUint(uint64(time), 10),
+		"p":           strconv.Itoa(int(threads)),
+		"salt_length": strconv.Itoa(len(salt)),
+	}, nil
 }
 
-func (c *argon2Scheme) needsUpdate(salt []byte, version int, time, memory uint32, threads uint8) bool {
-	return len(salt) < saltLength || version < argon2.Version || time < c.time || memory < c.memory || threads < c.threads
+func (c *argon2Scheme) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	salt, _, version, time, memory, threads, err := raw.Parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if time < c.time || memory < c.memory || threads < c.threads {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if len(salt) < saltLength {
+		reasons = append(reasons, scheme.ReasonSalt)
+	}
+
+	if version < argon2.Version {
+		reasons = append(reasons, scheme.ReasonVariant)
+	}
+
+	return
 }
 
-func (c *argon2Scheme) hash(password, stub string) (oldHashRaw []byte, newHash string, salt []byte, version int, memory, time uint32, threads uint8, err error) {
+func (c *argon2Scheme) hash(password []byte, stub string) (oldHashRaw []byte, newHash string, salt []byte, version int, memory, time uint32, threads uint8, err error) {
 	salt, oldHashRaw, version, time, memory, threads, err = raw.Parse(stub)
 	if err != nil {
 		return
 	}
 
-	return oldHashRaw, raw.Argon2(password, salt, time, memory, threads), salt, version, memory, time, threads, nil
+	if err = checkLimits(time, memory, c.limits()); err != nil {
+		return
+	}
+
+	newHash, err = raw.Argon2Bytes(password, salt, time, memory, threads)
+	return oldHashRaw, newHash, salt, version, memory, time, threads, err
 }
 
 func (c *argon2Scheme) makeStub() (string, error) {
 	buf := make([]byte, saltLength)
-	_, err := rand.Read(buf)
-	if err != nil {
+	if err := scheme.ReadSalt("argon2", c.rand, buf); err != nil {
 		return "", err
 	}
 
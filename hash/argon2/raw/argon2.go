@@ -6,9 +6,11 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
+	"github.com/pchchv/pass/scheme"
 	"golang.org/x/crypto/argon2"
 )
 
@@ -19,14 +21,16 @@ const (
 )
 
 var (
-	ErrInvalidStub         = errors.New("invalid argon2 password stub")
-	ErrMissingTime         = errors.New("time parameter (t) is missing")
-	ErrParseConfig         = errors.New("hash config section has wrong number of parameters")
-	ErrParseVersion        = errors.New("version section has wrong number of parameters")
-	ErrMissingMemory       = errors.New("memory parameter (m) is missing")
-	ErrMissingVersion      = errors.New("version parameter (v) is missing")
-	ErrMissingParallelism  = errors.New("parallelism parameter (p) is missing")
-	ErrInvalidKeyValuePair = errors.New("invalid argon2 key-value pair")
+	ErrInvalidStub         error = scheme.Malformed("argon2", "", nil)
+	ErrMissingTime         error = scheme.Malformed("argon2", "t", errors.New("parameter is missing"))
+	ErrParseConfig         error = scheme.Malformed("argon2", "config", errors.New("wrong number of parameters"))
+	ErrParseVersion        error = scheme.Malformed("argon2", "version", errors.New("wrong number of parameters"))
+	ErrMissingMemory       error = scheme.Malformed("argon2", "m", errors.New("parameter is missing"))
+	ErrMissingVersion      error = scheme.Malformed("argon2", "v", errors.New("parameter is missing"))
+	ErrMissingParallelism  error = scheme.Malformed("argon2", "p", errors.New("parameter is missing"))
+	ErrInvalidKeyValuePair error = scheme.Malformed("argon2", "", errors.New("invalid key-value pair"))
+	ErrInvalidTime         error = scheme.Unsupported("argon2", "t", nil)
+	ErrInvalidThreads      error = scheme.Unsupported("argon2", "p", nil)
 )
 
 // Wrapper for golang.org/x/crypto/argon2
@@ -37,15 +41,30 @@ var (
 // time, memory and threads are parameters for argon2.
 //
 // Returns hash in argon2 encoding.
-func Argon2(password string, salt []byte, time, memory uint32, threads uint8) string {
-	bytePassword := []byte(password)
+// Returns ErrInvalidTime or ErrInvalidThreads if time or threads are zero.
+func Argon2(password string, salt []byte, time, memory uint32, threads uint8) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
 
-	hash := argon2.Key(bytePassword, salt, time, memory, threads, 32)
+	return Argon2Bytes(b, salt, time, memory, threads)
+}
+
+// Argon2Bytes is like Argon2, but takes the password as a byte slice.
+func Argon2Bytes(password, salt []byte, time, memory uint32, threads uint8) (string, error) {
+	if time < 1 {
+		return "", ErrInvalidTime
+	}
+
+	if threads < 1 {
+		return "", ErrInvalidThreads
+	}
+
+	hash := argon2.Key(password, salt, time, memory, threads, 32)
 
 	strHash := base64.RawStdEncoding.EncodeToString(hash)
 	strSalt := base64.RawStdEncoding.EncodeToString(salt)
 
-	return fmt.Sprintf("$argon2i$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads, strSalt, strHash)
+	return fmt.Sprintf("$argon2i$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads, strSalt, strHash), nil
 }
 
 // Parse parses an argon2 encoded hash.
@@ -117,6 +136,11 @@ func Parse(stub string) (salt, hash []byte, version int, time, memory uint32, pa
 		return
 	}
 
+	if val < 1 {
+		err = ErrInvalidTime
+		return
+	}
+
 	time = uint32(val)
 
 	// Parallelism parameter.
@@ -126,17 +150,25 @@ func Parse(stub string) (salt, hash []byte, version int, time, memory uint32, pa
 		return
 	}
 
+	if val < 1 || val > math.MaxUint8 {
+		err = ErrInvalidThreads
+		return
+	}
+
 	parallelism = uint8(val)
 
 	// Decode salt.
 	salt, err = base64.RawStdEncoding.DecodeString(parts[2])
 	if err != nil {
+		err = scheme.Malformed("argon2", "salt", err)
 		return
 	}
 
 	// Decode hash if present.
 	if len(parts) >= 4 {
-		hash, err = base64.RawStdEncoding.DecodeString(parts[3])
+		if hash, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+			err = scheme.Malformed("argon2", "hash", err)
+		}
 	}
 
 	return
@@ -144,6 +176,7 @@ func Parse(stub string) (salt, hash []byte, version int, time, memory uint32, pa
 
 func parseKeyValue(pairs string) (result map[string]uint64, err error) {
 	parameterParts := strings.Split(pairs, ",")
+	result = make(map[string]uint64, len(parameterParts))
 
 	for _, parameter := range parameterParts {
 		parts := strings.SplitN(parameter, "=", 2)
@@ -153,7 +186,7 @@ func parseKeyValue(pairs string) (result map[string]uint64, err error) {
 
 		parsedint, err := strconv.ParseUint(parts[1], 10, 32)
 		if err != nil {
-			return result, err
+			return result, scheme.Malformed("argon2", parts[0], err)
 		}
 
 		result[parts[0]] = parsedint
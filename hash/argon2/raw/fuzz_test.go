@@ -0,0 +1,22 @@
+package raw
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add("foobar", "$argon2i$v=19$m=32768,t=4,p=4$c29tZXNhbHRzb21lYWxrdA$HcTlbOnOAzJ2dUrlgHnNwC0yallJ/Gl2NbAWqg4IukA")
+	f.Add("foobar", "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ")
+	f.Add("foobar", "$argon2i$v=19$m=64,t=0,p=0$c2FsdHNhbHQ$")
+	f.Add("foobar", "$argon2i$v=19$m=64,t=1,p=256$$")
+	f.Add("foobar", "$argon2i$v$m,t,p$$")
+
+	f.Fuzz(func(t *testing.T, password, stub string) {
+		salt, _, _, time, memory, threads, err := Parse(stub)
+		if err != nil || time > 2 || memory > 256 {
+			return
+		}
+
+		if _, err := Argon2(password, salt, time, memory, threads); err != nil {
+			t.Errorf("Argon2(%q, %x, %d, %d, %d): %v", password, salt, time, memory, threads, err)
+		}
+	})
+}
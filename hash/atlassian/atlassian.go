@@ -0,0 +1,109 @@
+// Package atlassian implements the {PKCS5S2} PBKDF2-SHA1 format
+// used by Atlassian products such as Jira, Confluence and Crowd.
+//
+// The format is compatible with atlassian_pbkdf2_sha1 from Python's passlib.
+// It uses fixed parameters and stores the base64 encoded salt and key together.
+package atlassian
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/pchchv/pass/hash/pbkdf2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+const (
+	Rounds     = 10000 // PBKDF2 iterations used by the format.
+	SaltLength = 16    // Length of the binary salt.
+
+	ident     = "{PKCS5S2}"
+	keyLength = 32
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("atlassian", "", nil)
+
+	// Verify-only implementation of Scheme for {PKCS5S2} hashes.
+	// Use New to obtain a Scheme which can also produce new hashes.
+	Crypter scheme.Scheme
+)
+
+func init() {
+	Crypter = scheme.VerifyOnly(New())
+}
+
+type atlassianScheme struct {
+	rand io.Reader
+}
+
+// New returns a Scheme implementing {PKCS5S2} hashes.
+func New() scheme.Scheme {
+	return &atlassianScheme{}
+}
+
+func (s *atlassianScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *atlassianScheme) HashBytes(password []byte) (string, error) {
+	salt := make([]byte, SaltLength)
+	if err := scheme.ReadSalt("atlassian", s.rand, salt); err != nil {
+		return "", err
+	}
+
+	return hash(password, salt), nil
+}
+
+func (s *atlassianScheme) Verify(password, stub string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, stub)
+}
+
+func (s *atlassianScheme) VerifyBytes(password []byte, stub string) error {
+	if !s.SupportsStub(stub) {
+		return ErrInvalidStub
+	}
+
+	b, err := base64.StdEncoding.DecodeString(stub[len(ident):])
+	if err != nil || len(b) != SaltLength+keyLength {
+		return ErrInvalidStub
+	}
+
+	if !scheme.SecureCompare(stub, hash(password, b[:SaltLength])) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *atlassianScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, ident)
+}
+
+func (s *atlassianScheme) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (s *atlassianScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *atlassianScheme) String() string {
+	return "atlassian-pbkdf2-sha1"
+}
+
+func hash(password, salt []byte) string {
+	key := raw.Key(password, salt, Rounds, keyLength, sha1.New)
+	return ident + base64.StdEncoding.EncodeToString(append(salt[:SaltLength:SaltLength], key...))
+}
@@ -0,0 +1,44 @@
+package atlassian
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range []struct {
+		password string
+		hash     string
+	}{
+		{"password", "{PKCS5S2}AAECAwQFBgcICQoLDA0OD44+L3PD62OQqBq7yBAcA0OwF6ev//tatl4TTwkJ3Mos"},
+		{"", "{PKCS5S2}ZGVmZ2hpamtsbW5vcHFyc0JkhfZLxVodblN7ixa1ZP6mcZGPKUU/l8QKZr9zfD6Z"},
+	} {
+		if !Crypter.SupportsStub(test.hash) {
+			t.Errorf("crypter reports not supporting %s", test.hash)
+		}
+
+		if err := Crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := Crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	if _, err := Crypter.Hash("helloworld"); err != scheme.ErrVerifyOnly {
+		t.Errorf("verify-only crypter produced a hash: %v", err)
+	}
+
+	hash, err := New().Hash("helloworld")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := Crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+}
@@ -1,19 +1,57 @@
 // The bcrypt package implements the bcrypt password hashing mechanism.
 //
-// Note that bcrypt truncates passwords to 72 characters in length.
+// Hashes of all variants ($2$, $2a$, $2b$, $2x$, $2y$) are verified,
+// new hashes use $2b$. $2$ and $2x$ hashes always need an update.
+//
+// Note that bcrypt truncates passwords to 72 bytes in length.
+// How longer passwords are handled is determined by a TruncationPolicy.
 // Consider using a more modern hashing scheme, such as scrypt or sha-crypt.
 // If you must use bcrypt, use bcrypt-sha256 instead.
 package bcrypt
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 
+	"github.com/pchchv/pass/hash/bcrypt/raw"
 	"github.com/pchchv/pass/scheme"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// Implementation of Scheme implementing bcrypt.
-var Crypter scheme.Scheme
+// TruncationPolicy determines how passwords longer than
+// raw.MaxPasswordLength bytes are hashed.
+type TruncationPolicy int
+
+const (
+	// Truncate ignores all bytes beyond the limit, as bcrypt
+	// itself and Python's passlib do.
+	// Verified hashes matching only because of the truncation
+	// are reported by the scheme, so that a Context can upgrade them
+	// if its preferred scheme does not truncate.
+	Truncate TruncationPolicy = iota
+	// TruncateError refuses to hash long passwords with ErrPasswordTooLong.
+	// Existing hashes of long passwords are still verified
+	// using the truncated password.
+	TruncateError
+	// Prehash transparently replaces long passwords by their base64 encoded
+	// SHA256 digest before hashing. Such hashes are only verified by
+	// schemes using the same policy. Existing hashes of truncated long
+	// passwords are still verified.
+	Prehash
+)
+
+var (
+	// Returned by Hash for passwords exceeding raw.MaxPasswordLength bytes
+	// when using the TruncateError policy.
+	ErrPasswordTooLong error = scheme.Rejected("bcrypt", "password", errors.New("password exceeds the length limit"))
+
+	// Implementation of Scheme implementing bcrypt.
+	// Long passwords are truncated.
+	Crypter scheme.Scheme
+)
 
 // The recommended cost for bcrypt.
 // This may change with subsequent releases.
@@ -26,38 +64,132 @@ func init() {
 
 // New creates a new scheme implementing bcrypt.
 // The recommended cost is RecommendedCost.
+// Long passwords are truncated.
 func New(cost int) scheme.Scheme {
+	return NewWithPolicy(cost, Truncate)
+}
+
+// NewWithPolicy is like New, but policy determines how passwords
+// longer than raw.MaxPasswordLength bytes are handled.
+func NewWithPolicy(cost int, policy TruncationPolicy) scheme.Scheme {
 	return &bcryptScheme{
-		Cost: cost,
+		Cost:   cost,
+		Policy: policy,
 	}
 }
 
 type bcryptScheme struct {
-	Cost int
+	Cost     int
+	Policy   TruncationPolicy
+	rand     io.Reader
+	ceilings *scheme.Limits
 }
 
-func (s *bcryptScheme) Hash(password string) (hash string, err error) {
-	h, err := bcrypt.GenerateFromPassword([]byte(password), s.Cost)
-	if err != nil {
+func (s *bcryptScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *bcryptScheme) HashBytes(password []byte) (hash string, err error) {
+	if len(password) > raw.MaxPasswordLength {
+		switch s.Policy {
+		case TruncateError:
+			return "", ErrPasswordTooLong
+		case Prehash:
+			password = prehash(password)
+			defer scheme.Zero(password)
+		}
+	}
+
+	salt := make([]byte, 16)
+	if err = scheme.ReadSalt("bcrypt", s.rand, salt); err != nil {
 		return
 	}
 
-	return string(h), nil
+	return raw.CryptBytes(password, raw.EncodeSalt(salt), s.Cost, "2b")
 }
 
-func (s *bcryptScheme) Verify(password, hash string) (err error) {
-	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	if err == bcrypt.ErrMismatchedHashAndPassword {
-		return scheme.ErrInvalidPassword
-	}
+func (s *bcryptScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
 
+func (s *bcryptScheme) VerifyBytes(password []byte, hash string) (err error) {
+	_, err = s.VerifyTruncated(password, hash)
 	return
 }
 
+func (s *bcryptScheme) VerifyTruncated(password []byte, hash string) (truncated bool, err error) {
+	if err = s.CheckLimits(hash, s.limits()); err != nil {
+		return false, err
+	}
+
+	if len(password) > raw.MaxPasswordLength && s.Policy == Prehash {
+		p := prehash(password)
+		err = verify(p, hash)
+		scheme.Zero(p)
+		if err != scheme.ErrInvalidPassword {
+			return false, err
+		}
+		// Fall back to hashes of the truncated password.
+	}
+
+	if err = verify(password, hash); err != nil {
+		return false, err
+	}
+
+	return len(password) > raw.MaxPasswordLength, nil
+}
+
+func (s *bcryptScheme) Truncates(password []byte) bool {
+	return len(password) > raw.MaxPasswordLength && s.Policy == Truncate
+}
+
+func (s *bcryptScheme) CheckLimits(stub string, limits scheme.Limits) error {
+	_, cost, _, _, err := raw.Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return limits.CheckLogRounds("bcrypt", "cost", cost)
+}
+
+// limits returns the configured or default limits, raised to allow the configured cost.
+func (s *bcryptScheme) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if s.ceilings != nil {
+		limits = *s.ceilings
+	}
+
+	if limits.MaxLogRounds != 0 && s.Cost > limits.MaxLogRounds {
+		limits.MaxLogRounds = s.Cost
+	}
+
+	return limits
+}
+
 func (s *bcryptScheme) SupportsStub(stub string) bool {
 	return len(stub) >= 3 && stub[0] == '$' && stub[1] == '2' &&
 		(stub[2] == '$' || (len(stub) >= 4 && stub[3] == '$' &&
-			(stub[2] == 'a' || stub[2] == 'b' || stub[2] == 'y')))
+			(stub[2] == 'a' || stub[2] == 'b' || stub[2] == 'x' || stub[2] == 'y')))
+}
+
+func (s *bcryptScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *bcryptScheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.ceilings = &limits
+
+	return &cc
 }
 
 func (s *bcryptScheme) String() string {
@@ -65,10 +197,66 @@ func (s *bcryptScheme) String() string {
 }
 
 func (s *bcryptScheme) NeedsUpdate(stub string) bool {
-	cost, err := bcrypt.Cost([]byte(stub))
+	return len(s.UpdateReasons(stub)) != 0
+}
+
+func (s *bcryptScheme) Params(stub string) (map[string]string, error) {
+	variant, cost, _, _, err := raw.Parse(stub)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return cost < s.Cost
+	return map[string]string{
+		"variant": variant,
+		"cost":    strconv.Itoa(cost),
+	}, nil
+}
+
+func (s *bcryptScheme) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	variant, cost, _, _, err := raw.Parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if cost < s.Cost {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if variant == "2" || variant == "2x" {
+		reasons = append(reasons, scheme.ReasonVariant)
+	}
+
+	return
+}
+
+// verify verifies the password as is, without applying any policy.
+func verify(password []byte, hash string) (err error) {
+	variant, cost, salt, checksum, err := raw.Parse(hash)
+	if err != nil {
+		return
+	}
+
+	newHash, err := raw.CryptBytes(password, salt, cost, variant)
+	if err != nil {
+		return
+	}
+
+	if !scheme.SecureCompare(checksum, newHash[len(newHash)-raw.ChecksumLength:]) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return
+}
+
+// prehash returns the base64 encoded SHA256 digest of password,
+// which fits within the bcrypt length limit.
+// The caller should zero the result after use.
+func prehash(password []byte) []byte {
+	h := sha256.Sum256(password)
+	defer scheme.Zero(h[:])
+
+	b := make([]byte, base64.StdEncoding.EncodedLen(len(h)))
+	base64.StdEncoding.Encode(b, h[:])
+
+	return b
 }
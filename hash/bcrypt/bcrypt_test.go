@@ -0,0 +1,77 @@
+package bcrypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+var (
+	long      = strings.Repeat("a", 80)
+	truncated = long[:72]
+)
+
+func TestTruncate(t *testing.T) {
+	s := New(4)
+	hash, err := s.Hash(long)
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := s.Verify(truncated, hash); err != nil {
+		t.Errorf("truncated password not accepted: %v", err)
+	}
+
+	tr, err := s.(scheme.Truncater).VerifyTruncated([]byte(long), hash)
+	if err != nil || !tr {
+		t.Errorf("long password not reported as truncated: %v, %v", tr, err)
+	}
+
+	if !s.(scheme.Truncater).Truncates([]byte(long)) || s.(scheme.Truncater).Truncates([]byte(truncated)) {
+		t.Errorf("unexpected Truncates result")
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	s := NewWithPolicy(4, TruncateError)
+	if _, err := s.Hash(long); err != ErrPasswordTooLong {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+
+	hash, err := New(4).Hash(long)
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := s.Verify(long, hash); err != nil {
+		t.Errorf("existing hash of long password not accepted: %v", err)
+	}
+}
+
+func TestPrehash(t *testing.T) {
+	s := NewWithPolicy(4, Prehash)
+	hash, err := s.Hash(long)
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := s.Verify(truncated+"b", hash); err != scheme.ErrInvalidPassword {
+		t.Errorf("password sharing the first 72 bytes accepted: %v", err)
+	}
+
+	tr, err := s.(scheme.Truncater).VerifyTruncated([]byte(long), hash)
+	if err != nil || tr {
+		t.Errorf("prehashed password reported as truncated: %v, %v", tr, err)
+	}
+
+	legacy, err := New(4).Hash(long)
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	tr, err = s.(scheme.Truncater).VerifyTruncated([]byte(long), legacy)
+	if err != nil || !tr {
+		t.Errorf("truncated hash not verified as truncated: %v, %v", tr, err)
+	}
+}
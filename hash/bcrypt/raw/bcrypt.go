@@ -0,0 +1,233 @@
+// Package raw provides a raw implementation of the bcrypt primitive.
+// Unlike golang.org/x/crypto/bcrypt, it hashes using a caller-supplied salt
+// and supports all historical variants of the format:
+//
+//	$2$    original OpenBSD bcrypt, the key excludes the terminating NUL
+//	$2a$   crypt_blowfish semantics, as used by PHP and glibc/libxcrypt
+//	$2b$   current OpenBSD bcrypt
+//	$2x$   crypt_blowfish's sign extension bug, for verifying old hashes
+//	$2y$   crypt_blowfish's name for the correct algorithm, identical to $2b$
+//
+// For $2a$, crypt_blowfish alters the hash of the rare passwords whose
+// keys are not affected by the sign extension bug despite containing
+// 8-bit characters, so that they cannot collide with $2x$ hashes.
+// Such $2a$ hashes differ from the ones produced by OpenBSD.
+package raw
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+	"golang.org/x/crypto/blowfish"
+)
+
+const (
+	MinCost = 4  // Minimum cost allowed by bcrypt.
+	MaxCost = 31 // Maximum cost allowed by bcrypt.
+	// Length of an encoded bcrypt salt.
+	SaltLength = 22
+	// Length of an encoded bcrypt checksum.
+	ChecksumLength = 31
+	// bcrypt ignores any password bytes beyond this length.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrInvalidStub    error = scheme.Malformed("bcrypt", "", nil)
+	ErrInvalidCost    error = scheme.Unsupported("bcrypt", "cost", nil)
+	ErrInvalidSalt    error = scheme.Malformed("bcrypt", "salt", nil)
+	ErrInvalidVariant error = scheme.Unsupported("bcrypt", "variant", nil)
+
+	b64   = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+	magic = []byte("OrpheanBeholderScryDoubt")
+)
+
+// Crypt calculates bcrypt.
+// The password is truncated to MaxPasswordLength bytes.
+// salt must be a SaltLength character bcrypt-base64 encoded salt.
+// cost must be in the range MinCost <= cost <= MaxCost.
+// variant is the ident of the produced hash: "2", "2a", "2b", "2x" or "2y".
+// The output is in modular crypt format.
+func Crypt(password, salt string, cost int, variant string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return CryptBytes(b, salt, cost, variant)
+}
+
+// CryptBytes is like Crypt, but takes the password as a byte slice.
+// The key material derived from the password is zeroed after use.
+func CryptBytes(password []byte, salt string, cost int, variant string) (string, error) {
+	if !validVariant(variant) {
+		return "", ErrInvalidVariant
+	}
+
+	if cost < MinCost || cost > MaxCost {
+		return "", ErrInvalidCost
+	}
+
+	csalt, err := decodeSalt(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key, initialKey := setupKey(password, variant)
+	defer scheme.Zero(key)
+	defer scheme.Zero(initialKey)
+
+	c, err := blowfish.NewSaltedCipher(initialKey, csalt)
+	if err != nil {
+		return "", err
+	}
+	defer func() { *c = blowfish.Cipher{} }()
+
+	for i := uint64(0); i < 1<<uint(cost); i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(csalt, c)
+	}
+
+	data := make([]byte, len(magic))
+	copy(data, magic)
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+
+	// Only 23 of the 24 bytes are encoded, as in the C implementations.
+	return fmt.Sprintf("$%s$%02d$%s%s", variant, cost, EncodeSalt(csalt), b64.EncodeToString(data[:23])), nil
+}
+
+// Parse parses a bcrypt hash or stub.
+// The format is as follows:
+//
+//	$variant$cost$saltchecksum   // hash
+//	$variant$cost$salt           // stub
+//
+// The checksum is empty for stubs.
+func Parse(stub string) (variant string, cost int, salt, checksum string, err error) {
+	parts := strings.Split(stub, "$")
+	if len(parts) != 4 || parts[0] != "" || !validVariant(parts[1]) || len(parts[2]) != 2 {
+		err = ErrInvalidStub
+		return
+	}
+
+	variant = parts[1]
+
+	if cost, err = strconv.Atoi(parts[2]); err != nil {
+		err = scheme.Malformed("bcrypt", "cost", err)
+		return
+	}
+
+	if cost < MinCost || cost > MaxCost {
+		err = ErrInvalidCost
+		return
+	}
+
+	if len(parts[3]) != SaltLength && len(parts[3]) != SaltLength+ChecksumLength {
+		err = ErrInvalidStub
+		return
+	}
+
+	salt, checksum = parts[3][:SaltLength], parts[3][SaltLength:]
+	if _, err = decodeSalt(salt); err != nil {
+		return
+	}
+
+	return
+}
+
+// EncodeSalt encodes a 16 byte binary salt using the bcrypt base64 alphabet.
+func EncodeSalt(salt []byte) string {
+	return b64.EncodeToString(salt)
+}
+
+func validVariant(variant string) bool {
+	switch variant {
+	case "2", "2a", "2b", "2x", "2y":
+		return true
+	}
+
+	return false
+}
+
+func decodeSalt(salt string) ([]byte, error) {
+	if len(salt) != SaltLength {
+		return nil, ErrInvalidSalt
+	}
+
+	csalt, err := b64.DecodeString(salt)
+	if err != nil {
+		return nil, ErrInvalidSalt
+	}
+
+	return csalt, nil
+}
+
+// setupKey returns the key used by the expensive key schedule
+// and the key used for the initial salted key setup.
+// The keys differ only for the crypt_blowfish $2a$ countermeasure.
+func setupKey(password []byte, variant string) (key, initialKey []byte) {
+	// The key includes the terminating NUL of the C string,
+	// except in the original $2$ variant.
+	n := len(password) + 1
+	if variant == "2" && len(password) != 0 {
+		n = len(password)
+	}
+
+	if n > MaxPasswordLength {
+		n = MaxPasswordLength
+	}
+
+	key = make([]byte, n)
+	copy(key, password)
+
+	if variant != "2a" && variant != "2x" {
+		return key, key
+	}
+
+	// Compute the 18 words of the key schedule as crypt_blowfish does,
+	// both correctly and with the sign extension bug.
+	var words [MaxPasswordLength]byte
+	var diff, sign uint32
+	for i, k := 0, 0; i < len(words); i += 4 {
+		var correct, buggy uint32
+		for j := 0; j < 4; j++ {
+			correct = correct<<8 | uint32(key[k])
+			buggy = buggy<<8 | uint32(int32(int8(key[k])))
+			if j != 0 {
+				sign |= buggy & 0x80
+			}
+
+			k = (k + 1) % len(key)
+		}
+
+		diff |= correct ^ buggy
+		if variant == "2x" {
+			correct = buggy
+		}
+
+		words[i], words[i+1], words[i+2], words[i+3] = byte(correct>>24), byte(correct>>16), byte(correct>>8), byte(correct)
+	}
+
+	scheme.Zero(key)
+	key = words[:]
+	if variant == "2x" {
+		return key, key
+	}
+
+	// If the bug would not have changed the key even though it contains
+	// 8-bit characters, alter the initial key so that the hash differs.
+	if diff == 0 && sign != 0 {
+		initialKey = make([]byte, len(key))
+		copy(initialKey, key)
+		initialKey[1] ^= 0x01
+
+		return key, initialKey
+	}
+
+	return key, key
+}
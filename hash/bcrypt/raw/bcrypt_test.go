@@ -0,0 +1,122 @@
+package raw
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+type test struct {
+	variant  string
+	password string
+	hash     string
+}
+
+// Generated with libxcrypt, matching the crypt_blowfish test vectors.
+var tests = []test{
+	{"2a", "\xa3", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.Sa7shbm4.OzKpvFnX1pQLmQW96oUlCq"},
+	{"2a", "\xff\xff\xa3", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.nqd1wy.pTMdcvrRWxyiGL2eMz.2a85."},
+	{"2a", "1\xa3345", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.ykhStlibHmUn6GomsWXnJvyvZx4vmvy"},
+	{"2a", "\xff\xa3345", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.nRht2l/HRhr6zmCp9vYUvvsqynflf9e"},
+	{"2a", "\xff\xa334\xff\xff\xff\xa3345", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.ZC1JEJ8Z4gPfpe1JOr/oyPXTWl9EFd."},
+	{"2a", "U*U", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.HZZLyzXp/APKnmE0fYxxsfwJ7bbQRT6"},
+	{"2a", "", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.Xd8Ewf..vaNdMfRkndPvNDo26MebAtu"},
+	{"2a", strings.Repeat("\xaa", 80), "$2a$05$/OK.fbVrR/bpIqNJ5ianF.swQOIzjOiJ9GHEPuhEkvqrUyvWhEMx6"},
+	{"2a", "password", "$2a$05$/OK.fbVrR/bpIqNJ5ianF.l.TBDAibFW.sOHlvKHwmGrkm1nQj2YC"},
+	{"2b", "\xa3", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.Sa7shbm4.OzKpvFnX1pQLmQW96oUlCq"},
+	{"2b", "\xff\xff\xa3", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e"},
+	{"2b", "1\xa3345", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.ykhStlibHmUn6GomsWXnJvyvZx4vmvy"},
+	{"2b", "\xff\xa3345", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.nRht2l/HRhr6zmCp9vYUvvsqynflf9e"},
+	{"2b", "\xff\xa334\xff\xff\xff\xa3345", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.o./n25XVfn6oAPaUvHe.Csk4zRfsYPi"},
+	{"2b", "U*U", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.HZZLyzXp/APKnmE0fYxxsfwJ7bbQRT6"},
+	{"2b", "", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.Xd8Ewf..vaNdMfRkndPvNDo26MebAtu"},
+	{"2b", strings.Repeat("\xaa", 80), "$2b$05$/OK.fbVrR/bpIqNJ5ianF.swQOIzjOiJ9GHEPuhEkvqrUyvWhEMx6"},
+	{"2b", "password", "$2b$05$/OK.fbVrR/bpIqNJ5ianF.l.TBDAibFW.sOHlvKHwmGrkm1nQj2YC"},
+	{"2x", "\xa3", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e"},
+	{"2x", "\xff\xff\xa3", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e"},
+	{"2x", "1\xa3345", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.o./n25XVfn6oAPaUvHe.Csk4zRfsYPi"},
+	{"2x", "\xff\xa3345", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.o./n25XVfn6oAPaUvHe.Csk4zRfsYPi"},
+	{"2x", "\xff\xa334\xff\xff\xff\xa3345", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.o./n25XVfn6oAPaUvHe.Csk4zRfsYPi"},
+	{"2x", "U*U", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.HZZLyzXp/APKnmE0fYxxsfwJ7bbQRT6"},
+	{"2x", "", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.Xd8Ewf..vaNdMfRkndPvNDo26MebAtu"},
+	{"2x", strings.Repeat("\xaa", 80), "$2x$05$/OK.fbVrR/bpIqNJ5ianF.ZUsA7SVAiHMR3X3fHShT3GIsiTbRUDy"},
+	{"2x", "password", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.l.TBDAibFW.sOHlvKHwmGrkm1nQj2YC"},
+	{"2y", "\xa3", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.Sa7shbm4.OzKpvFnX1pQLmQW96oUlCq"},
+	{"2y", "\xff\xff\xa3", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e"},
+	{"2y", "1\xa3345", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.ykhStlibHmUn6GomsWXnJvyvZx4vmvy"},
+	{"2y", "\xff\xa3345", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.nRht2l/HRhr6zmCp9vYUvvsqynflf9e"},
+	{"2y", "\xff\xa334\xff\xff\xff\xa3345", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.o./n25XVfn6oAPaUvHe.Csk4zRfsYPi"},
+	{"2y", "U*U", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.HZZLyzXp/APKnmE0fYxxsfwJ7bbQRT6"},
+	{"2y", "", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.Xd8Ewf..vaNdMfRkndPvNDo26MebAtu"},
+	{"2y", strings.Repeat("\xaa", 80), "$2y$05$/OK.fbVrR/bpIqNJ5ianF.swQOIzjOiJ9GHEPuhEkvqrUyvWhEMx6"},
+	{"2y", "password", "$2y$05$/OK.fbVrR/bpIqNJ5ianF.l.TBDAibFW.sOHlvKHwmGrkm1nQj2YC"},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, tst := range tests {
+		variant, cost, salt, _, err := Parse(tst.hash)
+		if err != nil || variant != tst.variant {
+			t.Errorf("unable to parse %s: %v", tst.hash, err)
+			continue
+		}
+
+		out, err := Crypt(tst.password, salt, cost, variant)
+		if err != nil || out != tst.hash {
+			t.Errorf("mismatch:\n  got: %#v (%v)\n  expected: %#v\n  password: %#v\n", out, err, tst.hash, tst.password)
+		}
+	}
+}
+
+func TestCryptOriginal(t *testing.T) {
+	// The original variant does not include the terminating NUL in the key,
+	// so a password and its repetition produce the same hash.
+	a, err := Crypt("ab", "/OK.fbVrR/bpIqNJ5ianF.", MinCost, "2")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	b, _ := Crypt("abab", "/OK.fbVrR/bpIqNJ5ianF.", MinCost, "2")
+	c, _ := Crypt("ab", "/OK.fbVrR/bpIqNJ5ianF.", MinCost, "2b")
+	if a != b || a[3:] == c[4:] {
+		t.Errorf("unexpected $2$ hashes: %s %s %s", a, b, c)
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	if _, err := Crypt("", "/OK.fbVrR/bpIqNJ5ianF.", MinCost, "2c"); err != ErrInvalidVariant {
+		t.Errorf("got %v, expected %v", err, ErrInvalidVariant)
+	}
+
+	if _, err := Crypt("", "/OK.fbVrR/bpIqNJ5ianF.", MaxCost+1, "2b"); err != ErrInvalidCost {
+		t.Errorf("got %v, expected %v", err, ErrInvalidCost)
+	}
+
+	if _, err := Crypt("", "/OK.fbVrR/bpIqNJ5ian", MinCost, "2b"); err != ErrInvalidSalt {
+		t.Errorf("got %v, expected %v", err, ErrInvalidSalt)
+	}
+
+	for _, stub := range []string{
+		"",
+		"$2b$",
+		"$2c$05$/OK.fbVrR/bpIqNJ5ianF.",
+		"$2b$5$/OK.fbVrR/bpIqNJ5ianF.",
+		"$2b$05$/OK.fbVrR/bpIqNJ5ianF",
+		"$2b$05$/OK.fbVrR/bpIqNJ5ianF.$",
+		"$2b$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3",
+		"$2b$05$/OK.fbVrR/bpIqNJ5ia!F.CE5elHaaO4EbggVDjb8P19RukzXSM3e",
+	} {
+		if _, _, _, _, err := Parse(stub); err == nil {
+			t.Errorf("invalid stub accepted: %q", stub)
+		}
+	}
+
+	if _, _, _, _, err := Parse("$2b$x5$/OK.fbVrR/bpIqNJ5ianF."); !errors.Is(err, scheme.ErrMalformedHash) {
+		t.Errorf("expected ErrMalformedHash for a non-numeric cost, got %v", err)
+	}
+
+	if _, _, _, _, err := Parse("$2b$32$/OK.fbVrR/bpIqNJ5ianF."); err != ErrInvalidCost || !errors.Is(err, scheme.ErrUnsupportedParameter) {
+		t.Errorf("expected ErrInvalidCost for an out of range cost, got %v", err)
+	}
+}
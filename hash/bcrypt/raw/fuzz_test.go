@@ -0,0 +1,22 @@
+package raw
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add("", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.7uG0VCzI2bS7j6ymqJi9CdcdxiRTWNy")
+	f.Add("U*U", "$2b$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")
+	f.Add("\xff\xa3", "$2x$05$/OK.fbVrR/bpIqNJ5ianF.CE5elHaaO4EbggVDjb8P19RukzXSM3e")
+	f.Add("", "$2$05$CCCCCCCCCCCCCCCCCCCCC.")
+	f.Add("", "$2y$99$")
+
+	f.Fuzz(func(t *testing.T, password, stub string) {
+		variant, cost, salt, _, err := Parse(stub)
+		if err != nil || cost > 5 {
+			return
+		}
+
+		if _, err := Crypt(password, salt, cost, variant); err != nil {
+			t.Errorf("Crypt(%q, %q, %d, %q): %v", password, salt, cost, variant, err)
+		}
+	})
+}
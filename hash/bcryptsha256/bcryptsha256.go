@@ -3,30 +3,55 @@
 // the equivalent bcrypt-sha256 scheme from Python passlib.
 // This is preferable to bcrypt because the prehash makes
 // the password length restriction in bcrypt irrelevant.
+//
+// Two versions of the format exist:
+//
+//	$bcrypt-sha256$v=2,t=2b,r=12$salt$hash   // v2, passlib 1.7.3 and later
+//	$bcrypt-sha256$2b,12$salt$hash           // v1
+//
+// Version 1 uses a plain SHA256 prehash, version 2 uses
+// HMAC-SHA256 keyed by the salt. Both versions are verified,
+// new hashes use version 2 and version 1 hashes need an update.
 package bcryptsha256
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/pass/hash/bcrypt"
+	"github.com/pchchv/pass/hash/bcrypt/raw"
 	"github.com/pchchv/pass/scheme"
 )
 
-// The recommended cost for bcrypt-sha256.
-const RecommendedCost = bcrypt.RecommendedCost
+const (
+	// The recommended cost for bcrypt-sha256.
+	RecommendedCost = bcrypt.RecommendedCost
+	// The version of the format produced by default.
+	RecommendedVersion = 2
 
-// An implementation of Scheme implementing
-// Python's passlib `$bcrypt-sha256$` bcrypt variant.
-// This is bcrypt with a SHA256 prehash,
-// which removes bcrypt's password length limitation.
-var Crypter scheme.Scheme
+	ident = "$bcrypt-sha256$"
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("bcrypt-sha256", "", nil)
+
+	// An implementation of Scheme implementing
+	// Python's passlib `$bcrypt-sha256$` bcrypt variant.
+	// This is bcrypt with a SHA256 prehash,
+	// which removes bcrypt's password length limitation.
+	Crypter scheme.Scheme
+)
 
 type schemeSHA256 struct {
-	underlying scheme.Scheme
-	cost       int
+	cost     int
+	version  int
+	rand     io.Reader
+	ceilings *scheme.Limits
 }
 
 func init() {
@@ -34,62 +59,243 @@ func init() {
 }
 
 // Instantiates a new Scheme implementing bcrypt with the given cost.
+// New hashes use version 2 of the format.
 func New(cost int) scheme.Scheme {
 	return &schemeSHA256{
-		underlying: bcrypt.New(cost),
-		cost:       cost,
+		cost:    cost,
+		version: RecommendedVersion,
+	}
+}
+
+// Instantiates a new Scheme implementing bcrypt with the given cost,
+// which produces version 1 hashes for compatibility with passlib
+// releases older than 1.7.3.
+func NewV1(cost int) scheme.Scheme {
+	return &schemeSHA256{
+		cost:    cost,
+		version: 1,
 	}
 }
 
 func (s *schemeSHA256) Hash(password string) (string, error) {
-	p := s.prehash(password)
-	h, err := s.underlying.Hash(p)
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *schemeSHA256) HashBytes(password []byte) (string, error) {
+	buf := make([]byte, 16)
+	if err := scheme.ReadSalt("bcrypt-sha256", s.rand, buf); err != nil {
+		return "", err
+	}
+
+	salt := raw.EncodeSalt(buf)
+	p := prehash(s.version, password, salt)
+	defer scheme.Zero(p)
+
+	h, err := raw.CryptBytes(p, salt, s.cost, "2b")
 	if err != nil {
 		return "", err
 	}
 
-	return mangle(h), nil
+	return format(s.version, "2b", s.cost, salt, h[len(h)-raw.ChecksumLength:]), nil
 }
 
 func (s *schemeSHA256) Verify(password, hash string) error {
-	p := s.prehash(password)
-	return s.underlying.Verify(p, demangle(hash))
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *schemeSHA256) VerifyBytes(password []byte, hash string) error {
+	version, variant, cost, salt, checksum, err := parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if err = s.CheckLimits(hash, s.limits()); err != nil {
+		return err
+	}
+
+	p := prehash(version, password, salt)
+	defer scheme.Zero(p)
+
+	h, err := raw.CryptBytes(p, salt, cost, variant)
+	if err != nil {
+		return err
+	}
+
+	if !scheme.SecureCompare(checksum, h[len(h)-raw.ChecksumLength:]) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
 }
 
 func (s *schemeSHA256) NeedsUpdate(stub string) bool {
-	return s.underlying.NeedsUpdate(demangle(stub))
+	return len(s.UpdateReasons(stub)) != 0
+}
+
+func (s *schemeSHA256) Params(stub string) (map[string]string, error) {
+	version, variant, cost, _, _, err := parse(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"version": strconv.Itoa(version),
+		"variant": variant,
+		"cost":    strconv.Itoa(cost),
+	}, nil
+}
+
+func (s *schemeSHA256) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	version, _, cost, _, _, err := parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if cost < s.cost {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if version < s.version {
+		reasons = append(reasons, scheme.ReasonVariant)
+	}
+
+	return
+}
+
+func (s *schemeSHA256) CheckLimits(stub string, limits scheme.Limits) error {
+	_, _, cost, _, _, err := parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return limits.CheckLogRounds("bcrypt-sha256", "cost", cost)
+}
+
+// limits returns the configured or default limits, raised to allow the configured cost.
+func (s *schemeSHA256) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if s.ceilings != nil {
+		limits = *s.ceilings
+	}
+
+	if limits.MaxLogRounds != 0 && s.cost > limits.MaxLogRounds {
+		limits.MaxLogRounds = s.cost
+	}
+
+	return limits
 }
 
 func (s *schemeSHA256) SupportsStub(stub string) bool {
-	return strings.HasPrefix(stub, "$bcrypt-sha256$") && s.underlying.SupportsStub(demangle(stub))
+	_, _, _, _, _, err := parse(stub)
+	return err == nil
+}
+
+func (s *schemeSHA256) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *schemeSHA256) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.ceilings = &limits
+
+	return &cc
 }
 
 func (s *schemeSHA256) String() string {
+	if s.version == 1 {
+		return fmt.Sprintf("bcrypt-sha256-v1(%d)", s.cost)
+	}
+
 	return fmt.Sprintf("bcrypt-sha256(%d)", s.cost)
 }
 
-func (s *schemeSHA256) prehash(password string) string {
-	h := sha256.New()
-	h.Write([]byte(password))
+// prehash returns the base64 encoded digest of password used as the bcrypt key.
+// The caller should zero the result after use.
+func prehash(version int, password []byte, salt string) []byte {
+	var sum [sha256.Size]byte
+	defer scheme.Zero(sum[:])
+
+	if version == 1 {
+		sum = sha256.Sum256(password)
+	} else {
+		h := hmac.New(sha256.New, []byte(salt))
+		h.Write(password)
+		h.Sum(sum[:0])
+	}
+
+	b := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+	base64.StdEncoding.Encode(b, sum[:])
 
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return b
 }
 
-func mangle(hash string) string {
-	parts := strings.Split(hash[1:], "$")
-	salt := parts[2][0:22]
-	h := parts[2][22:]
+func format(version int, variant string, cost int, salt, checksum string) string {
+	if version == 1 {
+		return fmt.Sprintf("%s%s,%d$%s$%s", ident, variant, cost, salt, checksum)
+	}
 
-	return "$bcrypt-sha256$" + parts[0] + "," + parts[1] + "$" + salt + "$" + h
+	return fmt.Sprintf("%sv=%d,t=%s,r=%d$%s$%s", ident, version, variant, cost, salt, checksum)
 }
 
-func demangle(stub string) string {
-	if strings.HasPrefix(stub, "$bcrypt-sha256$2") {
-		parts := strings.Split(stub[15:], "$")
-		parts0 := strings.Split(parts[0], ",")
+// parse parses a bcrypt-sha256 hash or stub of either version.
+// The checksum is empty for stubs.
+func parse(stub string) (version int, variant string, cost int, salt, checksum string, err error) {
+	if !strings.HasPrefix(stub, ident) {
+		err = ErrInvalidStub
+		return
+	}
 
-		return "$" + parts0[0] + "$" + fmt.Sprintf("%02s", parts0[1]) + "$" + parts[1] + parts[2]
-	} else {
-		return stub
+	parts := strings.Split(stub[len(ident):], "$")
+	if len(parts) < 2 || len(parts) > 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	var costStr string
+	params := strings.Split(parts[0], ",")
+	switch {
+	case len(params) == 2:
+		// 2b,12
+		version, variant, costStr = 1, params[0], params[1]
+	case len(params) == 3 && params[0] == "v=2" && strings.HasPrefix(params[1], "t=") && strings.HasPrefix(params[2], "r="):
+		// v=2,t=2b,r=12
+		version, variant, costStr = 2, params[1][2:], params[2][2:]
+	default:
+		err = ErrInvalidStub
+		return
+	}
+
+	if variant != "2a" && variant != "2b" {
+		err = ErrInvalidStub
+		return
+	}
+
+	if cost, err = strconv.Atoi(costStr); err != nil || cost < raw.MinCost || cost > raw.MaxCost {
+		err = ErrInvalidStub
+		return
 	}
+
+	salt = parts[1]
+	if len(salt) != raw.SaltLength {
+		err = ErrInvalidStub
+		return
+	}
+
+	if len(parts) == 3 {
+		checksum = parts[2]
+		if len(checksum) != 0 && len(checksum) != raw.ChecksumLength {
+			err = ErrInvalidStub
+		}
+	}
+
+	return
 }
@@ -0,0 +1,86 @@
+package bcryptsha256
+
+import "testing"
+
+type test struct {
+	password string
+	hash     string
+}
+
+var tests = []test{
+	// From passlib 1.7: version 1
+	{"", "$bcrypt-sha256$2a,5$E/e/2AOhqM5W/KJTFQzLce$F6dYSxOdAEoJZO2eoHUZWZljW/e0TXO"},
+	{"password", "$bcrypt-sha256$2a,5$5Hg1DKFqPE8C2aflZ5vVoe$12BjNE0p7axMg55.Y/mHsYiVuFBDQyu"},
+	// From passlib 1.7: version 2
+	{"", "$bcrypt-sha256$v=2,t=2b,r=5$E/e/2AOhqM5W/KJTFQzLce$WFPIZKtDDTriqWwlmRFfHiOTeheAZWe"},
+	{"password", "$bcrypt-sha256$v=2,t=2b,r=5$5Hg1DKFqPE8C2aflZ5vVoe$wOK1VFFtS8IGTrGa7.h5fs0u84qyPbS"},
+	{"táБℓə", "$bcrypt-sha256$v=2,t=2b,r=5$.US1fQ4TQS.ZTz/uJ5Kyn.$pzzgp40k8reM1CuQb03PvE0IDPQSdV6"},
+	{"abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123xyz", "$bcrypt-sha256$v=2,t=2b,r=5$X1g1nh3g0v4h6970O68cxe$zC/1UDUG2ofEXB6Onr2vvyFzfhEOS3S"},
+}
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range tests {
+		if !Crypter.SupportsStub(test.hash) {
+			t.Errorf("crypter reports not supporting %s", test.hash)
+		}
+
+		if err := Crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := Crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	hash, err := New(5).Hash("helloworld")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if hash[:len("$bcrypt-sha256$v=2,t=2b,r=5$")] != "$bcrypt-sha256$v=2,t=2b,r=5$" {
+		t.Errorf("unexpected hash format %s", hash)
+	}
+
+	if err := Crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if !Crypter.NeedsUpdate(hash) {
+		t.Errorf("hash with low cost does not need an update")
+	}
+
+	hash, err = NewV1(5).Hash("helloworld")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := Crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if !New(5).NeedsUpdate(hash) {
+		t.Errorf("version 1 hash does not need an update")
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	for _, stub := range []string{
+		"$bcrypt-sha256$",
+		"$bcrypt-sha256$2a,5",
+		"$bcrypt-sha256$2x,5$E/e/2AOhqM5W/KJTFQzLce$F6dYSxOdAEoJZO2eoHUZWZljW/e0TXO",
+		"$bcrypt-sha256$2a,xx$E/e/2AOhqM5W/KJTFQzLce$F6dYSxOdAEoJZO2eoHUZWZljW/e0TXO",
+		"$bcrypt-sha256$v=3,t=2b,r=5$E/e/2AOhqM5W/KJTFQzLce$WFPIZKtDDTriqWwlmRFfHiOTeheAZWe",
+		"$bcrypt-sha256$v=2,t=2b,r=5$E/e/2AOhqM5W$WFPIZKtDDTriqWwlmRFfHiOTeheAZWe",
+	} {
+		if Crypter.SupportsStub(stub) {
+			t.Errorf("invalid stub supported: %s", stub)
+		}
+
+		if err := Crypter.Verify("", stub); err == nil {
+			t.Errorf("invalid stub accepted: %s", stub)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package cisco implements the Cisco IOS type 8 ($8$, PBKDF2-SHA256)
+// and type 9 ($9$, scrypt) secret formats.
+//
+// Both formats use fixed parameters, a 14 character salt and
+// a base64 encoding with the "./0-9A-Za-z" alphabet.
+package cisco
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	pbkdf2 "github.com/pchchv/pass/hash/pbkdf2/raw"
+	scrypt "github.com/pchchv/pass/hash/scrypt/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+const (
+	Type8Rounds = 20000 // PBKDF2 iterations used by type 8 secrets.
+	Type9N      = 16384 // scrypt N parameter used by type 9 secrets.
+	Type9r      = 1     // scrypt r parameter used by type 9 secrets.
+	Type9p      = 1     // scrypt p parameter used by type 9 secrets.
+
+	alphabet   = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	saltLength = 14
+	keyLength  = 32
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("cisco", "", nil)
+
+	// Verify-only implementations of Scheme for type 8 and type 9 secrets.
+	// Use NewType8 and NewType9 to obtain schemes which can also produce new hashes.
+	Type8Crypter scheme.Scheme
+	Type9Crypter scheme.Scheme
+
+	b64        = base64.NewEncoding(alphabet).WithPadding(base64.NoPadding)
+	hashLength = 4 + saltLength + b64.EncodedLen(keyLength)
+)
+
+func init() {
+	Type8Crypter = scheme.VerifyOnly(NewType8())
+	Type9Crypter = scheme.VerifyOnly(NewType9())
+}
+
+type ciscoScheme struct {
+	ident string
+	name  string
+	kdf   func(password, salt []byte) ([]byte, error)
+	rand  io.Reader
+}
+
+// NewType8 returns a Scheme implementing Cisco type 8 secrets.
+func NewType8() scheme.Scheme {
+	return &ciscoScheme{
+		ident: "$8$",
+		name:  "cisco-type8",
+		kdf: func(password, salt []byte) ([]byte, error) {
+			return pbkdf2.Key(password, salt, Type8Rounds, keyLength, sha256.New), nil
+		},
+	}
+}
+
+// NewType9 returns a Scheme implementing Cisco type 9 secrets.
+func NewType9() scheme.Scheme {
+	return &ciscoScheme{
+		ident: "$9$",
+		name:  "cisco-type9",
+		kdf: func(password, salt []byte) ([]byte, error) {
+			return scrypt.Key(password, salt, Type9N, Type9r, Type9p, keyLength)
+		},
+	}
+}
+
+func (s *ciscoScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *ciscoScheme) HashBytes(password []byte) (string, error) {
+	salt := make([]byte, saltLength)
+	if err := scheme.ReadSalt("cisco", s.rand, salt); err != nil {
+		return "", err
+	}
+
+	for i := range salt {
+		salt[i] = alphabet[salt[i]&0x3F]
+	}
+
+	return s.hash(password, string(salt))
+}
+
+func (s *ciscoScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *ciscoScheme) VerifyBytes(password []byte, hash string) error {
+	if !s.SupportsStub(hash) || len(hash) != hashLength || hash[3+saltLength] != '$' {
+		return ErrInvalidStub
+	}
+
+	newHash, err := s.hash(password, hash[3:3+saltLength])
+	if err != nil {
+		return err
+	}
+
+	if !scheme.SecureCompare(hash, newHash) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *ciscoScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, s.ident)
+}
+
+func (s *ciscoScheme) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (s *ciscoScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *ciscoScheme) String() string {
+	return s.name
+}
+
+func (s *ciscoScheme) hash(password []byte, salt string) (string, error) {
+	key, err := s.kdf(password, []byte(salt))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s$%s", s.ident, salt, b64.EncodeToString(key)), nil
+}
@@ -0,0 +1,56 @@
+package cisco
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range []struct {
+		crypter  scheme.Scheme
+		password string
+		hash     string
+	}{
+		// From the hashcat example hashes.
+		{Type8Crypter, "hashcat", "$8$TnGX/fE4KGHOVU$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk"},
+		{Type9Crypter, "hashcat", "$9$2MJBozw/9R3UsU$2lFhcKvpghcyw8deP25GOfyZaagyUOGBymkryvOdfo6"},
+	} {
+		if !test.crypter.SupportsStub(test.hash) {
+			t.Errorf("%v does not support %s", test.crypter, test.hash)
+		}
+
+		if err := test.crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := test.crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+
+		if err := test.crypter.Verify(test.password, test.hash[:40]); err != ErrInvalidStub {
+			t.Errorf("truncated hash not rejected: %v", err)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	if _, err := Type8Crypter.Hash("helloworld"); err != scheme.ErrVerifyOnly {
+		t.Errorf("verify-only crypter produced a hash: %v", err)
+	}
+
+	for _, crypter := range []scheme.Scheme{NewType8(), NewType9()} {
+		hash, err := crypter.Hash("helloworld")
+		if err != nil {
+			t.Fatalf("recieved error whilst hashing password: %v", err)
+		}
+
+		if err := crypter.Verify("helloworld", hash); err != nil {
+			t.Errorf("valid password not accepted: %v", err)
+		}
+
+		if err := crypter.Verify("goodbyeuniverse", hash); err == nil {
+			t.Errorf("invalid password accepted")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package disabled implements the markers of disabled accounts
+// used in /etc/shadow and compatible with the unix_disabled handler
+// of Python's passlib:
+//
+//	!              // locked, no password
+//	*              // no password, login disabled
+//	!$6$salt$hash  // locked, restorable with Unlock
+//
+// The scheme never verifies a password, so that a Context including
+// it rejects disabled accounts with ErrDisabled instead of
+// scheme.ErrUnsupportedScheme.
+package disabled
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("disabled", "", nil)
+
+	// Returned by Verify for all disabled hashes.
+	// It is of kind scheme.ErrPolicyRejected.
+	ErrDisabled error = scheme.Rejected("disabled", "", errors.New("account is disabled"))
+
+	// Returned by Unlock for markers which do not wrap a hash.
+	ErrNoHash error = scheme.Malformed("disabled", "", errors.New("no hash to restore"))
+
+	// Scheme implementation of the disabled markers.
+	// Hash fails with scheme.ErrVerifyOnly; use Disable instead.
+	Crypter scheme.Scheme = disabledScheme{}
+)
+
+type disabledScheme struct{}
+
+// Disable returns hash marked as disabled by prefixing it with "!".
+// Hashes which are already disabled are returned unchanged.
+func Disable(hash string) string {
+	if Crypter.SupportsStub(hash) {
+		return hash
+	}
+
+	return "!" + hash
+}
+
+// Unlock returns the hash wrapped by a disabled marker,
+// or ErrNoHash if the marker does not wrap a hash, as for "!" or "*".
+// It returns ErrInvalidStub if hash is not disabled.
+func Unlock(hash string) (string, error) {
+	if !Crypter.SupportsStub(hash) {
+		return "", ErrInvalidStub
+	}
+
+	inner := strings.TrimLeft(hash, "!")
+	if inner == "" || strings.HasPrefix(hash, "*") {
+		return "", ErrNoHash
+	}
+
+	return inner, nil
+}
+
+func (s disabledScheme) Hash(password string) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s disabledScheme) HashBytes(password []byte) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s disabledScheme) Verify(password, hash string) error {
+	return s.VerifyBytes(nil, hash)
+}
+
+func (s disabledScheme) VerifyBytes(password []byte, hash string) error {
+	if !s.SupportsStub(hash) {
+		return ErrInvalidStub
+	}
+
+	return ErrDisabled
+}
+
+func (s disabledScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "!") || strings.HasPrefix(stub, "*")
+}
+
+// Disabled reports whether stub is a disabled marker,
+// which is the case for all supported stubs.
+func (s disabledScheme) Disabled(stub string) bool {
+	return s.SupportsStub(stub)
+}
+
+// NeedsUpdate returns false, as disabled hashes never verify.
+func (s disabledScheme) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (s disabledScheme) String() string {
+	return "disabled"
+}
@@ -0,0 +1,55 @@
+package disabled
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+const hash = "$6$rounds=1000$saltsaltsalt$27HJYdjgIx2y6ZrJBBD5k5Y1jFXv5QY5HKgK7z/tt1t/0cpw3OP9oWKTfZs7kvjj/t5w5F5R6KNO5KIzEinw2."
+
+func TestVerify(t *testing.T) {
+	for _, stub := range []string{"!", "!!", "*", "*LK*", "!" + hash} {
+		if !Crypter.SupportsStub(stub) || Crypter.NeedsUpdate(stub) {
+			t.Errorf("unexpected support for %s", stub)
+		}
+
+		if err := Crypter.Verify("password", stub); err != ErrDisabled || !errors.Is(err, scheme.ErrPolicyRejected) {
+			t.Errorf("expected ErrDisabled for %s, got %v", stub, err)
+		}
+	}
+
+	if Crypter.SupportsStub(hash) {
+		t.Errorf("enabled hash supported")
+	}
+
+	if _, err := Crypter.Hash("password"); err != scheme.ErrVerifyOnly {
+		t.Errorf("expected ErrVerifyOnly, got %v", err)
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	disabled := Disable(hash)
+	if disabled != "!"+hash || Disable(disabled) != disabled {
+		t.Errorf("unexpected disabled hash %s", disabled)
+	}
+
+	if h, err := Unlock(disabled); err != nil || h != hash {
+		t.Errorf("Unlock: %s, %v", h, err)
+	}
+
+	if h, err := Unlock("!!" + hash); err != nil || h != hash {
+		t.Errorf("Unlock: %s, %v", h, err)
+	}
+
+	for _, stub := range []string{"!", "!!", "*", "*LK*"} {
+		if _, err := Unlock(stub); err != ErrNoHash {
+			t.Errorf("expected ErrNoHash for %s, got %v", stub, err)
+		}
+	}
+
+	if _, err := Unlock(hash); err != ErrInvalidStub {
+		t.Errorf("expected ErrInvalidStub, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package grub implements the grub.pbkdf2.sha512 format
+// produced by grub-mkpasswd-pbkdf2 and used in grub.cfg password lines:
+//
+//	grub.pbkdf2.sha512.rounds.HEXSALT.HEXHASH
+//
+// The format is compatible with grub_pbkdf2_sha512 from Python's passlib.
+package grub
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pchchv/pass/hash/pbkdf2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+const (
+	SaltLength        = 64    // Length of the binary salt, as used by grub-mkpasswd-pbkdf2.
+	KeyLength         = 64    // Length of the derived key, as used by grub-mkpasswd-pbkdf2.
+	RecommendedRounds = 10000 // Default number of rounds of grub-mkpasswd-pbkdf2.
+
+	ident = "grub.pbkdf2.sha512."
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("grub", "", nil)
+
+	// Implementation of Scheme performing grub.pbkdf2.sha512.
+	// Uses RecommendedRounds.
+	Crypter scheme.Scheme
+)
+
+func init() {
+	Crypter = New(RecommendedRounds)
+}
+
+type grubScheme struct {
+	rounds   int
+	rand     io.Reader
+	ceilings *scheme.Limits
+}
+
+// New returns a Scheme implementing grub.pbkdf2.sha512
+// with the given number of rounds.
+func New(rounds int) scheme.Scheme {
+	return &grubScheme{rounds: rounds}
+}
+
+func (s *grubScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *grubScheme) HashBytes(password []byte) (string, error) {
+	if s.rounds < raw.MinRounds || s.rounds > raw.MaxRounds {
+		return "", raw.ErrInvalidRounds
+	}
+
+	salt := make([]byte, SaltLength)
+	if err := scheme.ReadSalt("grub", s.rand, salt); err != nil {
+		return "", err
+	}
+
+	key := raw.Key(password, salt, s.rounds, KeyLength, sha512.New)
+	return fmt.Sprintf("%s%d.%s.%s", ident, s.rounds, encodeHex(salt), encodeHex(key)), nil
+}
+
+func (s *grubScheme) Verify(password, stub string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, stub)
+}
+
+func (s *grubScheme) VerifyBytes(password []byte, stub string) error {
+	rounds, salt, key, err := Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	if len(key) == 0 {
+		return scheme.ErrInvalidPassword
+	}
+
+	if err = s.limits().CheckRounds("grub", "rounds", rounds); err != nil {
+		return err
+	}
+
+	newKey := raw.Key(password, salt, rounds, KeyLength, sha512.New)
+	if !scheme.SecureCompare(string(key), string(newKey)) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *grubScheme) CheckLimits(stub string, limits scheme.Limits) error {
+	rounds, _, _, err := Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return limits.CheckRounds("grub", "rounds", rounds)
+}
+
+// limits returns the configured or default limits, raised to allow the configured rounds.
+func (s *grubScheme) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if s.ceilings != nil {
+		limits = *s.ceilings
+	}
+
+	if limits.MaxRounds != 0 && s.rounds > limits.MaxRounds {
+		limits.MaxRounds = s.rounds
+	}
+
+	return limits
+}
+
+func (s *grubScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, ident)
+}
+
+func (s *grubScheme) NeedsUpdate(stub string) bool {
+	rounds, salt, _, err := Parse(stub)
+	return err == nil && (rounds < s.rounds || len(salt) < SaltLength)
+}
+
+func (s *grubScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *grubScheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.ceilings = &limits
+
+	return &cc
+}
+
+func (s *grubScheme) String() string {
+	return fmt.Sprintf("grub-pbkdf2-sha512(%d)", s.rounds)
+}
+
+// Parse parses a grub.pbkdf2.sha512 hash or stub.
+// The hexadecimal salt and hash may use either case.
+// The hash must be KeyLength bytes long.
+//
+//	grub.pbkdf2.sha512.rounds.HEXSALT.HEXHASH   // hash
+//	grub.pbkdf2.sha512.rounds.HEXSALT           // stub
+func Parse(stub string) (rounds int, salt, key []byte, err error) {
+	if !strings.HasPrefix(stub, ident) {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(stub[len(ident):], ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(parts[0], 10, 31)
+	if err != nil {
+		err = scheme.Malformed("grub", "rounds", err)
+		return
+	}
+
+	if n < raw.MinRounds {
+		err = raw.ErrInvalidRounds
+		return
+	}
+
+	rounds = int(n)
+
+	if salt, err = hex.DecodeString(parts[1]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	if len(parts) == 3 {
+		if key, err = hex.DecodeString(parts[2]); err != nil || len(key) != KeyLength {
+			key, err = nil, ErrInvalidStub
+		}
+	}
+
+	return
+}
+
+// grub-mkpasswd-pbkdf2 emits upper case hexadecimal.
+func encodeHex(b []byte) string {
+	return strings.ToUpper(hex.EncodeToString(b))
+}
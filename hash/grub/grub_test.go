@@ -0,0 +1,87 @@
+package grub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/pass/hash/pbkdf2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range []struct {
+		password string
+		hash     string
+	}{
+		{"password", "grub.pbkdf2.sha512.10000.000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F202122232425262728292A2B2C2D2E2F303132333435363738393A3B3C3D3E3F.DE25072AD1C2279350AA009DE388C0072AFD49313679A3CE2C980BE1F1AFB6084E2FF4E0BF920D3E24902616F118C50CBC79A21C877C08A5FDE691F177769D7A"},
+		{"", "grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F.ABA72C39879408AA5CAADC066598AC8D072BE27D4550769747A8557F830C1AF90F013A5FE4158A6A9F8935ECC85F602F5699ACBF8D784A3DE4581AB8A7D88484"},
+		{"", "grub.pbkdf2.sha512.1000.000102030405060708090a0b0c0d0e0f.aba72c39879408aa5caadc066598ac8d072be27d4550769747a8557f830c1af90f013a5fe4158a6a9f8935ecc85f602f5699acbf8d784a3de4581ab8a7d88484"},
+	} {
+		if !Crypter.SupportsStub(test.hash) {
+			t.Errorf("crypter reports not supporting %s", test.hash)
+		}
+
+		if err := Crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := Crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+	}
+
+	if !Crypter.NeedsUpdate("grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F") {
+		t.Errorf("short salt and low rounds do not need an update")
+	}
+}
+
+func TestHash(t *testing.T) {
+	hash, err := Crypter.Hash("helloworld")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := Crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if err := Crypter.Verify("goodbyeuniverse", hash); err == nil {
+		t.Errorf("invalid password accepted")
+	}
+
+	if Crypter.NeedsUpdate(hash) {
+		t.Errorf("new hash needs an update")
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	for _, stub := range []string{
+		"grub.pbkdf2.sha512.",
+		"grub.pbkdf2.sha512.0.00.00",
+		"grub.pbkdf2.sha512.1000.XY.00",
+		"grub.pbkdf2.sha512.1000.00.00.00",
+		// Truncated and oversized keys.
+		"grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F.ABA72C39879408AA5CAADC066598AC8D",
+		"grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F." + strings.Repeat("00", 4096),
+	} {
+		if err := Crypter.Verify("", stub); err == nil {
+			t.Errorf("invalid stub accepted: %s", stub)
+		}
+	}
+
+	for _, stub := range []string{
+		"grub.pbkdf2.sha512.x.00.00",
+		"grub.pbkdf2.sha512.-1.00.00",
+		"grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F.ABA72C39879408AA5CAADC066598AC8D",
+		"grub.pbkdf2.sha512.1000.000102030405060708090A0B0C0D0E0F." + strings.Repeat("00", 4096),
+	} {
+		if _, _, _, err := Parse(stub); !errors.Is(err, scheme.ErrMalformedHash) {
+			t.Errorf("expected ErrMalformedHash for %.80s, got %v", stub, err)
+		}
+	}
+
+	if _, _, _, err := Parse("grub.pbkdf2.sha512.0.00.00"); err != raw.ErrInvalidRounds {
+		t.Errorf("expected ErrInvalidRounds, got %v", err)
+	}
+}
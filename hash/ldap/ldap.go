@@ -0,0 +1,337 @@
+// Package ldap implements the RFC 2307 userPassword schemes
+// used by LDAP directories such as OpenLDAP.
+//
+// The formats are compatible with the ldap_* handlers
+// of Python's passlib.
+// Salted and unsalted digests ({SSHA}, {SHA}, ...) are implemented directly,
+// {CRYPT} and {PBKDF2-*} delegate to the corresponding modular crypt schemes.
+//
+// The digest schemes are weak and should never be used to hash new passwords.
+// They should only be used to verify hashes imported from a directory,
+// so that they can be upgraded to a modern scheme.
+package ldap
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/pchchv/pass/hash/bcrypt"
+	"github.com/pchchv/pass/hash/pbkdf2"
+	"github.com/pchchv/pass/hash/sha2"
+	"github.com/pchchv/pass/scheme"
+)
+
+// Length of the salt generated by the salted digest schemes.
+const SaltLength = 8
+
+var (
+	ErrInvalidStub error = scheme.Malformed("ldap", "", nil)
+
+	// Scheme implementations of the unsalted
+	// {MD5}, {SHA}, {SHA256} and {SHA512} digests.
+	// Hashes produced by these schemes always need an update.
+	MD5Crypter    scheme.Scheme
+	SHA1Crypter   scheme.Scheme
+	SHA256Crypter scheme.Scheme
+	SHA512Crypter scheme.Scheme
+
+	// Scheme implementations of the salted
+	// {SMD5}, {SSHA}, {SSHA256} and {SSHA512} digests.
+	SaltedMD5Crypter    scheme.Scheme
+	SaltedSHA1Crypter   scheme.Scheme
+	SaltedSHA256Crypter scheme.Scheme
+	SaltedSHA512Crypter scheme.Scheme
+
+	// Scheme implementation of {CRYPT} supporting sha512-crypt,
+	// sha256-crypt and bcrypt hashes.
+	// New hashes are produced using sha512-crypt.
+	CryptCrypter scheme.Scheme
+
+	// Scheme implementations of {PBKDF2}, {PBKDF2-SHA256} and {PBKDF2-SHA512}.
+	// These are the passlib PBKDF2 formats with an LDAP prefix
+	// instead of the modular crypt ident.
+	PBKDF2SHA1Crypter   scheme.Scheme
+	PBKDF2SHA256Crypter scheme.Scheme
+	PBKDF2SHA512Crypter scheme.Scheme
+)
+
+func init() {
+	MD5Crypter = NewDigest("{MD5}", md5.New, false)
+	SHA1Crypter = NewDigest("{SHA}", sha1.New, false)
+	SHA256Crypter = NewDigest("{SHA256}", sha256.New, false)
+	SHA512Crypter = NewDigest("{SHA512}", sha512.New, false)
+
+	SaltedMD5Crypter = NewDigest("{SMD5}", md5.New, true)
+	SaltedSHA1Crypter = NewDigest("{SSHA}", sha1.New, true)
+	SaltedSHA256Crypter = NewDigest("{SSHA256}", sha256.New, true)
+	SaltedSHA512Crypter = NewDigest("{SSHA512}", sha512.New, true)
+
+	CryptCrypter = NewCrypt(sha2.Crypter512, sha2.Crypter256, bcrypt.Crypter)
+
+	PBKDF2SHA1Crypter = newWrapped("{PBKDF2}", "$pbkdf2$", pbkdf2.SHA1Crypter)
+	PBKDF2SHA256Crypter = newWrapped("{PBKDF2-SHA256}", "$pbkdf2-sha256$", pbkdf2.SHA256Crypter)
+	PBKDF2SHA512Crypter = newWrapped("{PBKDF2-SHA512}", "$pbkdf2-sha512$", pbkdf2.SHA512Crypter)
+}
+
+type digestScheme struct {
+	ident    string
+	hashFunc func() hash.Hash
+	salted   bool
+	rand     io.Reader
+}
+
+// NewDigest returns a Scheme implementing an RFC 2307 digest
+// with the given ident (e.g. "{SSHA}") and hash function.
+// If salted is true, the base64 payload is the digest of the
+// password followed by the salt, with the salt appended.
+func NewDigest(ident string, hf func() hash.Hash, salted bool) scheme.Scheme {
+	return &digestScheme{
+		ident:    ident,
+		hashFunc: hf,
+		salted:   salted,
+	}
+}
+
+func (s *digestScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *digestScheme) HashBytes(password []byte) (string, error) {
+	var salt []byte
+	if s.salted {
+		salt = make([]byte, SaltLength)
+		if err := scheme.ReadSalt("ldap", s.rand, salt); err != nil {
+			return "", err
+		}
+	}
+
+	return s.ident + s.encode(password, salt), nil
+}
+
+func (s *digestScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *digestScheme) VerifyBytes(password []byte, hash string) error {
+	salt, err := s.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if !scheme.SecureCompare(hash[len(s.ident):], s.encode(password, salt)) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *digestScheme) SupportsStub(stub string) bool {
+	return hasPrefixFold(stub, s.ident)
+}
+
+func (s *digestScheme) NeedsUpdate(stub string) bool {
+	_, err := s.parse(stub)
+	return err == nil && !s.salted
+}
+
+func (s *digestScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *digestScheme) String() string {
+	return fmt.Sprintf("ldap(%s)", s.ident)
+}
+
+func (s *digestScheme) encode(password, salt []byte) string {
+	h := s.hashFunc()
+	h.Write(password)
+	h.Write(salt)
+
+	return base64.StdEncoding.EncodeToString(append(h.Sum(nil), salt...))
+}
+
+func (s *digestScheme) parse(stub string) (salt []byte, err error) {
+	if !s.SupportsStub(stub) {
+		return nil, ErrInvalidStub
+	}
+
+	b, err := base64.StdEncoding.DecodeString(stub[len(s.ident):])
+	if err != nil {
+		return nil, ErrInvalidStub
+	}
+
+	size := s.hashFunc().Size()
+	if len(b) < size || (!s.salted && len(b) != size) {
+		return nil, ErrInvalidStub
+	}
+
+	return b[size:], nil
+}
+
+// wrappedScheme replaces an LDAP ident with the ident of
+// an underlying modular crypt format and delegates to the
+// first of its schemes supporting the result.
+type wrappedScheme struct {
+	ident      string
+	innerIdent string
+	schemes    []scheme.Scheme
+	// Returned by Hash if the source of randomness
+	// could not be passed to the first scheme.
+	err error
+}
+
+// NewCrypt returns a Scheme implementing {CRYPT} on top of the given schemes.
+// New hashes are produced using the first scheme,
+// and any of the schemes can be used to verify existing hashes.
+func NewCrypt(schemes ...scheme.Scheme) scheme.Scheme {
+	return newWrapped("{CRYPT}", "", schemes...)
+}
+
+func newWrapped(ident, innerIdent string, schemes ...scheme.Scheme) scheme.Scheme {
+	return &wrappedScheme{
+		ident:      ident,
+		innerIdent: innerIdent,
+		schemes:    schemes,
+	}
+}
+
+func (s *wrappedScheme) Hash(password string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+
+	h, err := s.schemes[0].Hash(password)
+	if err != nil {
+		return "", err
+	}
+
+	return s.ident + strings.TrimPrefix(h, s.innerIdent), nil
+}
+
+func (s *wrappedScheme) HashBytes(password []byte) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+
+	bs, ok := s.schemes[0].(scheme.BytesScheme)
+	if !ok {
+		return s.Hash(string(password))
+	}
+
+	h, err := bs.HashBytes(password)
+	if err != nil {
+		return "", err
+	}
+
+	return s.ident + strings.TrimPrefix(h, s.innerIdent), nil
+}
+
+func (s *wrappedScheme) Verify(password, hash string) error {
+	inner, stub := s.find(hash)
+	if inner == nil {
+		return ErrInvalidStub
+	}
+
+	return inner.Verify(password, stub)
+}
+
+func (s *wrappedScheme) VerifyBytes(password []byte, hash string) error {
+	inner, stub := s.find(hash)
+	if inner == nil {
+		return ErrInvalidStub
+	}
+
+	if bs, ok := inner.(scheme.BytesScheme); ok {
+		return bs.VerifyBytes(password, stub)
+	}
+
+	return inner.Verify(string(password), stub)
+}
+
+func (s *wrappedScheme) SupportsStub(stub string) bool {
+	inner, _ := s.find(stub)
+	return inner != nil
+}
+
+func (s *wrappedScheme) NeedsUpdate(stub string) bool {
+	inner, innerStub := s.find(stub)
+	return inner != nil && inner.NeedsUpdate(innerStub)
+}
+
+func (s *wrappedScheme) CheckLimits(stub string, limits scheme.Limits) error {
+	inner, innerStub := s.find(stub)
+	if inner == nil {
+		return ErrInvalidStub
+	}
+
+	if l, ok := inner.(scheme.Limiter); ok {
+		return l.CheckLimits(innerStub, limits)
+	}
+
+	return nil
+}
+
+// WithLimits passes limits to the schemes which support them.
+func (s *wrappedScheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.schemes = append([]scheme.Scheme(nil), s.schemes...)
+	for i, inner := range cc.schemes {
+		if ls, ok := inner.(scheme.LimitSetter); ok {
+			cc.schemes[i] = ls.WithLimits(limits)
+		}
+	}
+
+	return &cc
+}
+
+// WithRand passes r to the first scheme, which produces new hashes.
+func (s *wrappedScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.schemes = append([]scheme.Scheme(nil), s.schemes...)
+	cc.schemes[0], cc.err = scheme.WithRand(s.schemes[0], r)
+	if cc.err != nil {
+		cc.schemes[0] = s.schemes[0]
+	}
+
+	return &cc
+}
+
+func (s *wrappedScheme) String() string {
+	return fmt.Sprintf("ldap(%s)", s.ident)
+}
+
+func (s *wrappedScheme) find(stub string) (scheme.Scheme, string) {
+	if !hasPrefixFold(stub, s.ident) {
+		return nil, ""
+	}
+
+	innerStub := s.innerIdent + stub[len(s.ident):]
+	for _, inner := range s.schemes {
+		if inner.SupportsStub(innerStub) {
+			return inner, innerStub
+		}
+	}
+
+	return nil, ""
+}
+
+// RFC 2307 scheme names are case insensitive.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
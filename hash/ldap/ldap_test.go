@@ -0,0 +1,96 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+type test struct {
+	crypter  scheme.Scheme
+	password string
+	hash     string
+}
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range []test{
+		{MD5Crypter, "secret", "{MD5}Xr4ilOzQ4PCOq3aQ0qbuaQ=="},
+		{SHA1Crypter, "secret", "{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ="},
+		{SHA1Crypter, "secret", "{sha}5en6G6MezRroT3XKqkdPOmY/BfQ="},
+		{SHA256Crypter, "secret", "{SHA256}K7gNU3sdo+OL0wNhqoVWhr3g6s1xYv72ol/pe/Unols="},
+		{SHA512Crypter, "secret", "{SHA512}vSsar3708Jvp9Szi2NWZZ02Bqp1qRCFpbcTZPdBhnWgs5WtNZKnvCXdhztmeD2cmW192CF5bDufKRpayrW/isg=="},
+		{SaltedMD5Crypter, "secret", "{SMD5}yeWhvSFtvhMX4jDO9I847gECAwQFBgcI"},
+		{SaltedSHA1Crypter, "secret", "{SSHA}lHFzXul4wnzRItssVcTnvXWRjNgBAgMEBQYHCA=="},
+		{SaltedSHA256Crypter, "secret", "{SSHA256}A7N1lAy5bBb4T6qH9e85zAvHBmzNPhRFbZ105DjjWDIBAgMEBQYHCA=="},
+		{SaltedSHA512Crypter, "secret", "{SSHA512}KO8EsMPQTwZrxxbOkDAOOXEeVCc2grMQg1pnZwZhC1bBQLby8zCmFn7qTZRvoTd+yQdROQQNYHWpTUST4zjTdQECAwQFBgcI"},
+		{CryptCrypter, "secret", "{CRYPT}$5$rounds=1004$nacl$oiWPbm.kQ7.jTCZoOtdv7/tO5mWv/vxw5yTqlBagVR7"},
+		{CryptCrypter, "U*U*U*U*", "{CRYPT}$6$LKO/Ute40T3FNF95$6S/6T2YuOIHY0N3XpLKABJ3soYcXD9mB7uVbtEZDj/LNscVhZoZ9DEH.sBciDrMsHOWOoASbNLTypH/5X26gN0"},
+		{CryptCrypter, "abc", "{CRYPT}$2a$10$WvvTPHKwdBJ3uk0Z37EMR.hLA2W6N9AEBhEgrAOljy2Ae5MtaSIUi"},
+		{PBKDF2SHA1Crypter, "abc", "{PBKDF2}131000$bW0tJaT03huD8F6LcU4pRQ$dtV.m979atKXoe8dNNpMa43Gips"},
+		{PBKDF2SHA256Crypter, "abc", "{PBKDF2-SHA256}29000$2dsbYwxhzDlHqBWCMObc2w$GYnQVBLHvbjzDpZdOY8lZtkrE8lqbZ3zURM9rXMZv1A"},
+		{PBKDF2SHA512Crypter, "abc", "{PBKDF2-SHA512}25000$29s7h1BqzZnT.n8vBUDIGQ$80zmUh1Ytb8Gd1T.ik/eaFELNmu9gKUZYZZGlm15xqgHSSYvJTYZteFoy5qmAEdSSroYhFLFxW9IGn7lEqY2Sw"},
+	} {
+		if !test.crypter.SupportsStub(test.hash) {
+			t.Errorf("%v does not support %s", test.crypter, test.hash)
+			continue
+		}
+
+		if err := test.crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := test.crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+	}
+}
+
+func TestHashVerify(t *testing.T) {
+	for _, crypter := range []scheme.Scheme{
+		SHA1Crypter,
+		SaltedSHA1Crypter,
+		SaltedSHA512Crypter,
+		CryptCrypter,
+		PBKDF2SHA256Crypter,
+	} {
+		hash, err := crypter.Hash("helloworld")
+		if err != nil {
+			t.Fatalf("recieved error whilst hashing password: %v", err)
+		}
+
+		if !crypter.SupportsStub(hash) {
+			t.Errorf("%v does not support its own hash %s", crypter, hash)
+		}
+
+		if err := crypter.Verify("helloworld", hash); err != nil {
+			t.Errorf("valid password not accepted: %v", err)
+		}
+
+		if err := crypter.Verify("goodbyeuniverse", hash); err == nil {
+			t.Errorf("invalid password accepted")
+		}
+	}
+}
+
+func TestSupportsStub(t *testing.T) {
+	for _, stub := range []string{
+		"{SHA256}K7gNU3sdo+OL0wNhqoVWhr3g6s1xYv72ol/pe/Unols=",
+		"{SSHA}lHFzXul4wnzRItssVcTnvXWRjNgBAgMEBQYHCA==",
+		"{CRYPT}$1$salt$hash",
+		"$pbkdf2-sha256$29000$2dsbYwxhzDlHqBWCMObc2w$GYnQVBLHvbjzDpZdOY8lZtkrE8lqbZ3zURM9rXMZv1A",
+	} {
+		if SHA1Crypter.SupportsStub(stub) || CryptCrypter.SupportsStub(stub) || PBKDF2SHA256Crypter.SupportsStub(stub) {
+			t.Errorf("unexpected support for %s", stub)
+		}
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	if !SHA1Crypter.NeedsUpdate("{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=") {
+		t.Errorf("unsalted digest does not need an update")
+	}
+
+	if SaltedSHA1Crypter.NeedsUpdate("{SSHA}lHFzXul4wnzRItssVcTnvXWRjNgBAgMEBQYHCA==") {
+		t.Errorf("salted digest needs an update")
+	}
+}
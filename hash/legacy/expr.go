@@ -0,0 +1,243 @@
+package legacy
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+// Returned, wrapped, by Compile for invalid expressions.
+var ErrInvalidExpression = errors.New("invalid legacy hash expression")
+
+// The functions available in expressions.
+var functions = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha224": sha256.New224,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Compile returns a verify-only Scheme for hashes starting with prefix
+// whose digest is computed by expression, such as "sha1(md5($p).$s)".
+// Expressions are made of:
+//
+//	$p          the password
+//	$s          the salt, see Compose
+//	'text'      a literal string, without quotes
+//	f(x)        the lowercase hexadecimal digest of x, where f is one of
+//	            md5, sha1, sha224, sha256, sha384 or sha512
+//	x.y, x+y    the concatenation of x and y
+//
+// As in PHP, nested digests are hex-encoded. The outermost expression
+// must be a digest, which is compared to the stored digest decoded
+// according to WithEncoding. WithSalt is ignored, as the expression
+// places the salt; hashes have a salt if the expression uses $s.
+func Compile(prefix, expression string, opts ...Option) (scheme.Scheme, error) {
+	p := &parser{src: expression}
+	e, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidExpression, expression, err)
+	}
+
+	root, ok := e.(call)
+	if !ok {
+		return nil, fmt.Errorf("%w %q: the outermost expression must be a digest", ErrInvalidExpression, expression)
+	}
+
+	s := &digestScheme{prefix: prefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.root = root
+	s.salted = usesSalt(e)
+	s.expression = expression
+
+	return s, nil
+}
+
+// expr is a node of a compiled expression.
+type expr interface {
+	// eval returns a new buffer, which the caller should zero.
+	eval(password []byte, salt string) []byte
+}
+
+type variable byte
+
+func (v variable) eval(password []byte, salt string) []byte {
+	if v == 's' {
+		return []byte(salt)
+	}
+
+	return append([]byte(nil), password...)
+}
+
+type literal string
+
+func (l literal) eval(password []byte, salt string) []byte {
+	return []byte(l)
+}
+
+type concat []expr
+
+func (c concat) eval(password []byte, salt string) []byte {
+	var b []byte
+	for _, e := range c {
+		v := e.eval(password, salt)
+		b = append(b, v...)
+		scheme.Zero(v)
+	}
+
+	return b
+}
+
+type call struct {
+	hashFunc func() hash.Hash
+	arg      expr
+}
+
+// sum returns the raw digest of the argument.
+func (c call) sum(password []byte, salt string) []byte {
+	v := c.arg.eval(password, salt)
+	defer scheme.Zero(v)
+
+	h := c.hashFunc()
+	h.Write(v)
+
+	return h.Sum(nil)
+}
+
+func (c call) eval(password []byte, salt string) []byte {
+	sum := c.sum(password, salt)
+	defer scheme.Zero(sum)
+
+	b := make([]byte, hex.EncodedLen(len(sum)))
+	hex.Encode(b, sum)
+
+	return b
+}
+
+func usesSalt(e expr) bool {
+	switch e := e.(type) {
+	case variable:
+		return e == 's'
+	case concat:
+		for _, c := range e {
+			if usesSalt(c) {
+				return true
+			}
+		}
+	case call:
+		return usesSalt(e.arg)
+	}
+
+	return false
+}
+
+// parser is a recursive descent parser of expressions.
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) parse() (expr, error) {
+	e, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipSpace(); p.pos != len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos], p.pos)
+	}
+
+	return e, nil
+}
+
+func (p *parser) concat() (expr, error) {
+	var c concat
+	for {
+		e, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		c = append(c, e)
+		if p.skipSpace(); p.pos == len(p.src) || (p.src[p.pos] != '.' && p.src[p.pos] != '+') {
+			break
+		}
+
+		p.pos++
+	}
+
+	if len(c) == 1 {
+		return c[0], nil
+	}
+
+	return c, nil
+}
+
+func (p *parser) term() (expr, error) {
+	p.skipSpace()
+	rest := p.src[p.pos:]
+	switch {
+	case rest == "":
+		return nil, errors.New("unexpected end of expression")
+	case strings.HasPrefix(rest, "$p"), strings.HasPrefix(rest, "$s"):
+		p.pos += 2
+		return variable(rest[1]), nil
+	case rest[0] == '\'':
+		end := strings.IndexByte(rest[1:], '\'')
+		if end < 0 {
+			return nil, errors.New("unterminated string")
+		}
+
+		p.pos += end + 2
+		return literal(rest[1 : end+1]), nil
+	}
+
+	i := 0
+	for i < len(rest) && (rest[i] >= 'a' && rest[i] <= 'z' || rest[i] >= '0' && rest[i] <= '9') {
+		i++
+	}
+
+	name := rest[:i]
+	hf, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at offset %d", name, p.pos)
+	}
+
+	p.pos += i
+	if p.skipSpace(); p.pos == len(p.src) || p.src[p.pos] != '(' {
+		return nil, fmt.Errorf("expected ( after %s", name)
+	}
+
+	p.pos++
+	arg, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipSpace(); p.pos == len(p.src) || p.src[p.pos] != ')' {
+		return nil, fmt.Errorf("expected ) after the argument of %s", name)
+	}
+
+	p.pos++
+
+	return call{hf, arg}, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
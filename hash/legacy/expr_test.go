@@ -0,0 +1,58 @@
+package legacy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestCompile(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		hash       string
+	}{
+		{"sha1(md5($p).$s)", "$x$NaCl$bd38b6033904efe8a44dcf30c71d9c79e74060ec"},
+		{"md5($s + sha1($p))", "$x$NaCl$519333a5b0b422b6bcd4525e3c3a6bec"},
+		{"sha256('x:'.md5($p))", "$x$4110b9010359fc9ecfabf0bac361ee576b8b2d559dda30a98603637fcb731430"},
+		{"md5($p)", "$x$5F4DCC3B5AA765D61D8327DEB882CF99"},
+	} {
+		s, err := Compile("$x$", test.expression)
+		if err != nil {
+			t.Fatalf("%s: %v", test.expression, err)
+		}
+
+		if !s.SupportsStub(test.hash) || !s.NeedsUpdate(test.hash) {
+			t.Errorf("%s: unexpected support for %s", test.expression, test.hash)
+		}
+
+		if err := s.Verify("password", test.hash); err != nil {
+			t.Errorf("%s: unable to verify %s: %v", test.expression, test.hash, err)
+		}
+
+		if err := s.Verify("wrong", test.hash); !errors.Is(err, scheme.ErrPasswordMismatch) {
+			t.Errorf("%s: expected ErrPasswordMismatch, got %v", test.expression, err)
+		}
+
+		if _, err := s.Hash("password"); err != scheme.ErrVerifyOnly {
+			t.Errorf("expected ErrVerifyOnly, got %v", err)
+		}
+	}
+
+	for _, expression := range []string{
+		"",
+		"$p",
+		"md5($p).$s",
+		"md4($p)",
+		"md5($p",
+		"md5 $p",
+		"md5($q)",
+		"md5('salt)",
+		"md5($p))",
+		"md5($p.)",
+	} {
+		if _, err := Compile("$x$", expression); !errors.Is(err, ErrInvalidExpression) {
+			t.Errorf("%q: expected ErrInvalidExpression, got %v", expression, err)
+		}
+	}
+}
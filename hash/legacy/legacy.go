@@ -0,0 +1,188 @@
+// Package legacy implements verify-only schemes for the ad hoc digests
+// found in the databases of older applications, such as hex(md5(password))
+// or sha1(salt + password), so that they can be upgraded on login.
+//
+// Such digests are not self-describing and their salt is often stored
+// in a separate column. They are recognized by a prefix chosen by the
+// application, followed by the salt and the encoded digest (see Compose):
+//
+//	{LEGACY-MD5}5f4dcc3b5aa765d61d8327deb882cf99   // unsalted
+//	{LEGACY-SSHA1}salt$1a2b...                     // salted
+//
+// Other constructions, such as sha1(md5(password) + salt),
+// can be described with an expression, see Compile.
+//
+// The digests are weak, so hashes of these schemes always need an update.
+package legacy
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+// Encoding is the text encoding of a stored digest.
+type Encoding int
+
+const (
+	Hex    Encoding = iota // Hexadecimal, in either case.
+	Base64                 // Standard base64, with or without padding.
+)
+
+// Salt is the placement of the salt relative to the password.
+type Salt int
+
+const (
+	NoSalt     Salt = iota // digest(password)
+	SaltBefore             // digest(salt + password)
+	SaltAfter              // digest(password + salt)
+)
+
+var ErrInvalidStub error = scheme.Malformed("legacy", "", nil)
+
+type digestScheme struct {
+	prefix   string
+	encoding Encoding
+	salt     Salt
+
+	// The digest computed from the password and salt.
+	root       call
+	salted     bool
+	expression string
+}
+
+// Option configures a scheme created by New or Compile.
+type Option func(*digestScheme)
+
+// WithEncoding sets the encoding of the digest. The default is Hex.
+func WithEncoding(encoding Encoding) Option {
+	return func(s *digestScheme) { s.encoding = encoding }
+}
+
+// WithSalt sets the placement of the salt. The default is NoSalt.
+func WithSalt(salt Salt) Option {
+	return func(s *digestScheme) { s.salt = salt }
+}
+
+// New returns a verify-only Scheme for digests computed with hf,
+// such as crypto/md5.New or crypto/sha1.New, of hashes starting with prefix.
+// Hash fails with scheme.ErrVerifyOnly.
+func New(prefix string, hf func() hash.Hash, opts ...Option) scheme.Scheme {
+	s := &digestScheme{prefix: prefix}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	switch s.salt {
+	case SaltBefore:
+		s.root = call{hf, concat{variable('s'), variable('p')}}
+	case SaltAfter:
+		s.root = call{hf, concat{variable('p'), variable('s')}}
+	default:
+		s.root = call{hf, variable('p')}
+	}
+
+	s.salted = s.salt != NoSalt
+
+	return s
+}
+
+// Compose builds the hash of a legacy scheme from the prefix,
+// the stored salt (empty for unsalted digests) and the encoded digest,
+// typically when importing rows which store them in separate columns.
+// The salt is used as is and may contain any character.
+func Compose(prefix, salt, digest string) string {
+	if salt == "" {
+		return prefix + digest
+	}
+
+	return prefix + salt + "$" + digest
+}
+
+func (s *digestScheme) Hash(password string) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s *digestScheme) HashBytes(password []byte) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s *digestScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *digestScheme) VerifyBytes(password []byte, hash string) error {
+	salt, digest, err := s.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	sum := s.root.sum(password, salt)
+	defer scheme.Zero(sum)
+
+	if !scheme.SecureCompare(string(sum), string(digest)) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *digestScheme) SupportsStub(stub string) bool {
+	_, _, err := s.parse(stub)
+	return err == nil
+}
+
+// NeedsUpdate returns true for all supported hashes.
+func (s *digestScheme) NeedsUpdate(stub string) bool {
+	return s.SupportsStub(stub)
+}
+
+func (s *digestScheme) String() string {
+	if s.expression != "" {
+		return fmt.Sprintf("legacy(%s,%s)", s.prefix, s.expression)
+	}
+
+	return fmt.Sprintf("legacy(%s)", s.prefix)
+}
+
+// parse returns the salt and the decoded digest of a stub.
+func (s *digestScheme) parse(stub string) (salt string, digest []byte, err error) {
+	if !strings.HasPrefix(stub, s.prefix) {
+		return "", nil, ErrInvalidStub
+	}
+
+	encoded := stub[len(s.prefix):]
+	if s.salted {
+		if i := strings.LastIndexByte(encoded, '$'); i >= 0 {
+			salt, encoded = encoded[:i], encoded[i+1:]
+		}
+	}
+
+	if digest, err = decode(s.encoding, encoded); err != nil || len(digest) != s.root.hashFunc().Size() {
+		return "", nil, ErrInvalidStub
+	}
+
+	return salt, digest, nil
+}
+
+func decode(encoding Encoding, s string) ([]byte, error) {
+	switch encoding {
+	case Hex:
+		return hex.DecodeString(s)
+	case Base64:
+		if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return b, nil
+		}
+
+		return base64.RawStdEncoding.DecodeString(s)
+	default:
+		return nil, ErrInvalidStub
+	}
+}
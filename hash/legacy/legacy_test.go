@@ -0,0 +1,71 @@
+package legacy
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+type test struct {
+	scheme   scheme.Scheme
+	password string
+	hash     string
+}
+
+var tests = []test{
+	{New("{MD5}", md5.New), "password", "{MD5}5f4dcc3b5aa765d61d8327deb882cf99"},
+	{New("{MD5}", md5.New), "password", "{MD5}5F4DCC3B5AA765D61D8327DEB882CF99"},
+	{New("{SSHA1}", sha1.New, WithSalt(SaltBefore)), "password", Compose("{SSHA1}", "pep$per", "974188f418e1f74ff5958b912b971bf883c739ff")},
+	{New("$sha256$", sha256.New, WithSalt(SaltAfter), WithEncoding(Base64)), "password", "$sha256$NaCl$AoSAlxEEs3aR9BxDDlngf9TFrg9TMXsqouBs+N27/hA="},
+	{New("", sha512.New, WithEncoding(Base64)), "password", "sQnzu7wkTrgkQZF+0G1hi5AI3Qmzvv0bXgc5THBqi7mAsdd4Xll27ASbRt9fEyavWi6m0QP9B8lThf+rDKy8hg"},
+}
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range tests {
+		if !test.scheme.SupportsStub(test.hash) {
+			t.Errorf("%v reports not supporting %s", test.scheme, test.hash)
+		}
+
+		if err := test.scheme.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := test.scheme.Verify(test.password+"x", test.hash); !errors.Is(err, scheme.ErrPasswordMismatch) {
+			t.Errorf("invalid password accepted for %s: %v", test.hash, err)
+		}
+
+		if !test.scheme.NeedsUpdate(test.hash) {
+			t.Errorf("hash %s does not need an update", test.hash)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	if _, err := New("{MD5}", md5.New).Hash("password"); err != scheme.ErrVerifyOnly {
+		t.Errorf("expected ErrVerifyOnly, got %v", err)
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	s := New("{MD5}", md5.New)
+	for _, stub := range []string{
+		"{MD5}",
+		"{MD5}5f4dcc3b5aa765d61d8327deb882cf",
+		"{MD5}5f4dcc3b5aa765d61d8327deb882cf99aa",
+		"{MD5}salt$5f4dcc3b5aa765d61d8327deb882cf99",
+		"{SHA}5f4dcc3b5aa765d61d8327deb882cf99",
+	} {
+		if s.SupportsStub(stub) || s.NeedsUpdate(stub) {
+			t.Errorf("invalid stub supported: %s", stub)
+		}
+
+		if err := s.Verify("password", stub); !errors.Is(err, scheme.ErrMalformedHash) {
+			t.Errorf("expected ErrMalformedHash for %s, got %v", stub, err)
+		}
+	}
+}
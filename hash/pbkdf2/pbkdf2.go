@@ -6,12 +6,13 @@
 package pbkdf2
 
 import (
-	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	"fmt"
 	"hash"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/pass/hash/pbkdf2/raw"
@@ -41,6 +42,8 @@ type pbkdf2Scheme struct {
 	Ident    string
 	HashFunc func() hash.Hash
 	Rounds   int
+	rand     io.Reader
+	ceilings *scheme.Limits
 }
 
 func New(ident string, hf func() hash.Hash, rounds int) scheme.Scheme {
@@ -58,24 +61,42 @@ func init() {
 }
 
 func (s *pbkdf2Scheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *pbkdf2Scheme) HashBytes(password []byte) (string, error) {
 	salt := make([]byte, SaltLength)
-	if _, err := rand.Read(salt); err != nil {
+	if err := scheme.ReadSalt("pbkdf2", s.rand, salt); err != nil {
 		return "", err
 	}
 
-	hash := raw.Hash([]byte(password), salt, s.Rounds, s.HashFunc)
+	hash := raw.Hash(password, salt, s.Rounds, s.HashFunc)
 	newHash := fmt.Sprintf("%s%d$%s$%s", s.Ident, s.Rounds, raw.Base64Encode(salt), hash)
 
 	return newHash, nil
 }
 
 func (s *pbkdf2Scheme) Verify(password, stub string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, stub)
+}
+
+func (s *pbkdf2Scheme) VerifyBytes(password []byte, stub string) error {
 	_, rounds, salt, oldHash, err := raw.Parse(stub)
 	if err != nil {
 		return err
 	}
 
-	newHash := raw.Hash([]byte(password), salt, rounds, s.HashFunc)
+	if err = s.limits().CheckRounds("pbkdf2", "rounds", rounds); err != nil {
+		return err
+	}
+
+	newHash := raw.Hash(password, salt, rounds, s.HashFunc)
 
 	if len(newHash) == 0 || !scheme.SecureCompare(oldHash, newHash) {
 		return scheme.ErrInvalidPassword
@@ -84,11 +105,78 @@ func (s *pbkdf2Scheme) Verify(password, stub string) error {
 	return nil
 }
 
+func (s *pbkdf2Scheme) CheckLimits(stub string, limits scheme.Limits) error {
+	_, rounds, _, _, err := raw.Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return limits.CheckRounds("pbkdf2", "rounds", rounds)
+}
+
+// limits returns the configured or default limits, raised to allow the configured rounds.
+func (s *pbkdf2Scheme) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if s.ceilings != nil {
+		limits = *s.ceilings
+	}
+
+	if limits.MaxRounds != 0 && s.Rounds > limits.MaxRounds {
+		limits.MaxRounds = s.Rounds
+	}
+
+	return limits
+}
+
+func (s *pbkdf2Scheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *pbkdf2Scheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.ceilings = &limits
+
+	return &cc
+}
+
 func (s *pbkdf2Scheme) SupportsStub(stub string) bool {
 	return strings.HasPrefix(stub, s.Ident)
 }
 
 func (s *pbkdf2Scheme) NeedsUpdate(stub string) bool {
+	return len(s.UpdateReasons(stub)) != 0
+}
+
+func (s *pbkdf2Scheme) Params(stub string) (map[string]string, error) {
+	_, rounds, salt, _, err := raw.Parse(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"rounds":      strconv.Itoa(rounds),
+		"salt_length": strconv.Itoa(len(salt)),
+	}, nil
+}
+
+func (s *pbkdf2Scheme) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
 	_, rounds, salt, _, err := raw.Parse(stub)
-	return err == raw.ErrInvalidRounds || rounds < s.Rounds || len(salt) < SaltLength
+	if err == raw.ErrInvalidRounds {
+		return []scheme.UpdateReason{scheme.ReasonCost}
+	} else if err != nil {
+		return nil
+	}
+
+	if rounds < s.Rounds {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if len(salt) < SaltLength {
+		reasons = append(reasons, scheme.ReasonSalt)
+	}
+
+	return
 }
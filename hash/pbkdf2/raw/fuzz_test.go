@@ -0,0 +1,19 @@
+package raw
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add("password", "$pbkdf2-sha256$1212$4vjV83LKPjQzk31VI4E0Vw$hsYF68OiOUPdDZ1Fg.fJPeq1h/gXXY7acBp9/6c.tmQ")
+	f.Add("password", "$pbkdf2$1212$OB.dtnSEXZK8U5cgxU/GYQ")
+	f.Add("password", "$pbkdf2-sha512$")
+	f.Add("password", "$pbkdf2")
+
+	f.Fuzz(func(t *testing.T, password, stub string) {
+		hf, rounds, salt, _, err := Parse(stub)
+		if err != nil || rounds > 1000 {
+			return
+		}
+
+		Hash([]byte(password), salt, rounds, hf)
+	})
+}
@@ -4,23 +4,24 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
-	"errors"
-	"fmt"
 	"hash"
 	"strconv"
 	"strings"
+
+	"github.com/pchchv/pass/scheme"
 )
 
 var (
-	ErrInvalidRounds = errors.New("invalid number of rounds")
-	ErrInvalidStub   = errors.New("invalid stub")
-	hashMap          = map[string]func() hash.Hash{
-		"pbkdf2":        sha1.New,
-		"pbkdf2-sha256": sha256.New,
-		"pbkdf2-sha512": sha512.New,
-	}
+	ErrInvalidRounds error = scheme.Unsupported("pbkdf2", "rounds", nil)
+	ErrInvalidStub   error = scheme.Malformed("pbkdf2", "", nil)
 )
 
+var hashMap = map[string]func() hash.Hash{
+	"pbkdf2":        sha1.New,
+	"pbkdf2-sha256": sha256.New,
+	"pbkdf2-sha512": sha512.New,
+}
+
 func Parse(stub string) (hashFunc func() hash.Hash, rounds int, salt []byte, hash string, err error) {
 	var n uint64
 
@@ -30,7 +31,13 @@ func Parse(stub string) (hashFunc func() hash.Hash, rounds int, salt []byte, has
 		return
 	}
 
+	// $pbkdf2-sha256$rounds$salt$hash
 	parts := strings.Split(stub, "$")
+	if len(parts) < 4 || len(parts) > 5 {
+		err = ErrInvalidStub
+		return
+	}
+
 	if f, ok := hashMap[parts[1]]; ok {
 		hashFunc = f
 	} else {
@@ -53,11 +60,13 @@ func Parse(stub string) (hashFunc func() hash.Hash, rounds int, salt []byte, has
 
 	salt, err = Base64Decode(parts[3])
 	if err != nil {
-		err = fmt.Errorf("could not decode base64 salt")
+		err = scheme.Malformed("pbkdf2", "salt", err)
 		return
 	}
 
-	hash = parts[4]
+	if len(parts) == 5 {
+		hash = parts[4]
+	}
 
 	return
 }
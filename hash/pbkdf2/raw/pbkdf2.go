@@ -11,6 +11,15 @@ const (
 	MaxRounds = 0x7fffffff // setting at 32-bit signed integer limit for now
 )
 
+// Hash derives a key of the size of the hash function output
+// and returns it in passlib's adapted base64 encoding.
 func Hash(password, salt []byte, rounds int, hf func() hash.Hash) (hash string) {
-	return Base64Encode(pbkdf2.Key(password, salt, rounds, hf().Size(), hf))
+	return Base64Encode(Key(password, salt, rounds, hf().Size(), hf))
+}
+
+// Key derives a raw PBKDF2 key of keyLen bytes.
+// It is used by formats which encode the key differently
+// or use a key length other than the hash function output size.
+func Key(password, salt []byte, rounds, keyLen int, hf func() hash.Hash) []byte {
+	return pbkdf2.Key(password, salt, rounds, keyLen, hf)
 }
@@ -0,0 +1,193 @@
+// Package phpass implements the PHPass portable hash ($P$, $H$)
+// used by WordPress, phpBB and other PHP applications.
+//
+// PHPass is an iterated MD5 construction and is weak by modern standards.
+// It is provided so that imported users can log in and have their
+// hashes upgraded; NeedsUpdate always reports that an update is needed.
+package phpass
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pchchv/pass/hash/sha2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+const (
+	MinRounds = 7  // Minimum base-2 logarithm of the iteration count.
+	MaxRounds = 30 // Maximum base-2 logarithm of the iteration count.
+	// Base-2 logarithm of the iteration count used by WordPress ($P$B).
+	RecommendedRounds = 13
+
+	itoa64     = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	saltLength = 8
+	hashLength = 34
+)
+
+var (
+	ErrInvalidStub   error = scheme.Malformed("phpass", "", nil)
+	ErrInvalidRounds error = scheme.Unsupported("phpass", "rounds", nil)
+
+	// Verify-only implementation of Scheme for PHPass portable hashes.
+	// Use New to obtain a Scheme which can also produce new hashes.
+	Crypter scheme.Scheme
+)
+
+func init() {
+	Crypter = scheme.VerifyOnly(New(RecommendedRounds))
+}
+
+type phpassScheme struct {
+	rounds   int
+	rand     io.Reader
+	ceilings *scheme.Limits
+}
+
+// New returns a Scheme implementing PHPass portable hashes.
+// rounds is the base-2 logarithm of the iteration count,
+// in the range MinRounds <= rounds <= MaxRounds.
+func New(rounds int) scheme.Scheme {
+	return &phpassScheme{rounds: rounds}
+}
+
+func (s *phpassScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *phpassScheme) HashBytes(password []byte) (string, error) {
+	if s.rounds < MinRounds || s.rounds > MaxRounds {
+		return "", ErrInvalidRounds
+	}
+
+	buf := make([]byte, saltLength)
+	if err := scheme.ReadSalt("phpass", s.rand, buf); err != nil {
+		return "", err
+	}
+
+	for i := range buf {
+		buf[i] = itoa64[buf[i]&0x3F]
+	}
+
+	return CryptBytes(password, "$P$"+string(itoa64[s.rounds])+string(buf))
+}
+
+func (s *phpassScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *phpassScheme) VerifyBytes(password []byte, hash string) error {
+	if len(hash) != hashLength {
+		return ErrInvalidStub
+	}
+
+	if err := s.CheckLimits(hash, s.limits()); err != nil {
+		return err
+	}
+
+	newHash, err := CryptBytes(password, hash)
+	if err != nil {
+		return err
+	}
+
+	if !scheme.SecureCompare(hash, newHash) {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *phpassScheme) CheckLimits(stub string, limits scheme.Limits) error {
+	if len(stub) < 4 || !s.SupportsStub(stub) {
+		return ErrInvalidStub
+	}
+
+	return limits.CheckLogRounds("phpass", "rounds", strings.IndexByte(itoa64, stub[3]))
+}
+
+// limits returns the configured or default limits, raised to allow the configured rounds.
+func (s *phpassScheme) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if s.ceilings != nil {
+		limits = *s.ceilings
+	}
+
+	if limits.MaxLogRounds != 0 && s.rounds > limits.MaxLogRounds {
+		limits.MaxLogRounds = s.rounds
+	}
+
+	return limits
+}
+
+func (s *phpassScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$P$") || strings.HasPrefix(stub, "$H$")
+}
+
+func (s *phpassScheme) NeedsUpdate(stub string) bool {
+	return s.SupportsStub(stub)
+}
+
+func (s *phpassScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+
+	return &cc
+}
+
+func (s *phpassScheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	cc.ceilings = &limits
+
+	return &cc
+}
+
+func (s *phpassScheme) String() string {
+	return fmt.Sprintf("phpass(%d)", s.rounds)
+}
+
+// Crypt calculates a PHPass portable hash.
+// The setting is the first 12 characters of a hash:
+// the $P$ or $H$ ident, the encoded number of rounds and an 8 character salt.
+// Any characters following the setting are ignored.
+func Crypt(password, setting string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return CryptBytes(b, setting)
+}
+
+// CryptBytes is like Crypt, but takes the password as a byte slice.
+// The buffers derived from the password are zeroed after use.
+func CryptBytes(password []byte, setting string) (string, error) {
+	if len(setting) < 12 || !(strings.HasPrefix(setting, "$P$") || strings.HasPrefix(setting, "$H$")) {
+		return "", ErrInvalidStub
+	}
+
+	rounds := strings.IndexByte(itoa64, setting[3])
+	if rounds < MinRounds || rounds > MaxRounds {
+		return "", ErrInvalidRounds
+	}
+
+	buf := make([]byte, md5.Size+len(password))
+	defer scheme.Zero(buf)
+
+	// The first digest is of the salt followed by the password.
+	copy(buf[md5.Size-8:], setting[4:12])
+	copy(buf[md5.Size:], password)
+	sum := md5.Sum(buf[md5.Size-8:])
+
+	for i := 1 << rounds; i > 0; i-- {
+		copy(buf, sum[:])
+		sum = md5.Sum(buf)
+	}
+
+	return setting[:12] + raw.EncodeBase64(sum[:]), nil
+}
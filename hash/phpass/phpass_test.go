@@ -0,0 +1,75 @@
+package phpass
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+type test struct {
+	password string
+	hash     string
+}
+
+var tests = []test{
+	// From the PHPass test suite.
+	{"test12345", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0"},
+	// Generated with a reference implementation.
+	{"password", "$P$BabcdefghEP1Dc925xipBv72nvZxoc1"},
+	{"", "$H$9saltsaltsEAQSBdg4W01.E00fn91g."},
+	{"táБℓə", "$P$CabcdefghajEu8tBJ82c0Fhvy.wEiS1"},
+}
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range tests {
+		if !Crypter.SupportsStub(test.hash) {
+			t.Errorf("crypter reports not supporting %s", test.hash)
+		}
+
+		if err := Crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify password %s: %v", test.password, err)
+		}
+
+		if err := Crypter.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+
+		if !Crypter.NeedsUpdate(test.hash) {
+			t.Errorf("phpass hash does not need an update")
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	if _, err := Crypter.Hash("helloworld"); err != scheme.ErrVerifyOnly {
+		t.Errorf("verify-only crypter produced a hash: %v", err)
+	}
+
+	crypter := New(MinRounds)
+	hash, err := crypter.Hash("helloworld")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	if err := Crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if err := Crypter.Verify("goodbyeuniverse", hash); err == nil {
+		t.Errorf("invalid password accepted")
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	for _, stub := range []string{
+		"$P$",
+		"$P$9IQRaTwmf",
+		"$P$$IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+		"$P$zIQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+		"$Q$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+	} {
+		if err := Crypter.Verify("test12345", stub); err == nil {
+			t.Errorf("invalid stub accepted: %s", stub)
+		}
+	}
+}
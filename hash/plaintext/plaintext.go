@@ -0,0 +1,69 @@
+// Package plaintext implements a verify-only scheme for passwords
+// stored in plain text with the "{PLAIN}" prefix used by Dovecot,
+// so that such passwords can be upgraded on login:
+//
+//	{PLAIN}password
+package plaintext
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+const ident = "{PLAIN}"
+
+var (
+	ErrInvalidStub error = scheme.Malformed("plaintext", "", nil)
+
+	// Scheme implementation of {PLAIN}. Hash fails with scheme.ErrVerifyOnly
+	// and hashes of this scheme always need an update.
+	Crypter scheme.Scheme = plaintextScheme{}
+)
+
+type plaintextScheme struct{}
+
+func (s plaintextScheme) Hash(password string) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s plaintextScheme) HashBytes(password []byte) (string, error) {
+	return "", scheme.ErrVerifyOnly
+}
+
+func (s plaintextScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s plaintextScheme) VerifyBytes(password []byte, hash string) error {
+	if !s.SupportsStub(hash) {
+		return ErrInvalidStub
+	}
+
+	stored := []byte(hash[len(ident):])
+	defer scheme.Zero(stored)
+
+	if subtle.ConstantTimeCompare(password, stored) != 1 {
+		return scheme.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s plaintextScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, ident)
+}
+
+// NeedsUpdate returns true for all supported hashes.
+func (s plaintextScheme) NeedsUpdate(stub string) bool {
+	return s.SupportsStub(stub)
+}
+
+func (s plaintextScheme) String() string {
+	return fmt.Sprintf("plaintext(%s)", ident)
+}
@@ -0,0 +1,39 @@
+package plaintext
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestVerify(t *testing.T) {
+	for _, test := range []struct {
+		password string
+		hash     string
+	}{
+		{"password", "{PLAIN}password"},
+		{"", "{PLAIN}"},
+		{"táБℓə", "{PLAIN}táБℓə"},
+	} {
+		if !Crypter.SupportsStub(test.hash) || !Crypter.NeedsUpdate(test.hash) {
+			t.Errorf("unexpected support for %s", test.hash)
+		}
+
+		if err := Crypter.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := Crypter.Verify(test.password+"x", test.hash); !errors.Is(err, scheme.ErrPasswordMismatch) {
+			t.Errorf("expected ErrPasswordMismatch for %s, got %v", test.hash, err)
+		}
+	}
+
+	if err := Crypter.Verify("password", "password"); !errors.Is(err, scheme.ErrMalformedHash) {
+		t.Errorf("expected ErrMalformedHash, got %v", err)
+	}
+
+	if _, err := Crypter.Hash("password"); err != scheme.ErrVerifyOnly {
+		t.Errorf("expected ErrVerifyOnly, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+// Package prehash implements a scheme which hashes passwords with a
+// digest before passing them to another scheme, so that schemes with
+// a password length limit, such as bcrypt, or whose cost grows with
+// the password length, such as sha-crypt, can be used with long
+// passphrases. It generalizes the prehash of bcrypt-sha256.
+//
+// As in version 2 of bcrypt-sha256, the digest is an HMAC keyed by
+// a random salt, so that unsalted digests of the same passwords leaked
+// from other databases cannot be tested against the inner scheme
+// ("password shucking"). The digest, its encoding and the salt are
+// recorded in front of the hash of the inner scheme, which must use
+// the modular crypt format:
+//
+//	$prehash-hmac-sha256-b64$salt$2b$12$salthash   // prehashed $2b$12$salthash
+//	$prehash-sha256-b64$2b$12$salthash             // unkeyed
+//
+// Unkeyed hashes are verified for compatibility, but always need an update.
+//
+// The base64 encoding of a SHA-512 or BLAKE2b digest is 88 bytes long,
+// which exceeds the 72 bytes used by bcrypt; use SHA-256 with bcrypt.
+package prehash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Digest is the hash function applied to passwords.
+type Digest string
+
+const (
+	SHA256  Digest = "sha256"  // SHA-256.
+	SHA512  Digest = "sha512"  // SHA-512.
+	BLAKE2b Digest = "blake2b" // BLAKE2b-512.
+)
+
+// Encoding is the text encoding of the digest passed to the inner scheme.
+// The digest is encoded, as some schemes stop at NUL bytes.
+type Encoding string
+
+const (
+	Base64 Encoding = "b64" // Standard base64 with padding, as in bcrypt-sha256.
+	Hex    Encoding = "hex" // Lowercase hexadecimal.
+)
+
+const (
+	// Length of the salt keying the HMAC.
+	SaltLength = 16
+
+	ident = "$prehash-"
+	keyed = "hmac-"
+)
+
+var (
+	ErrInvalidStub error = scheme.Malformed("prehash", "", nil)
+	// Returned by Hash if the digest of the scheme is unknown.
+	ErrUnsupportedDigest error = scheme.Unsupported("prehash", "digest", nil)
+	// Returned by Hash if the encoding of the scheme is unknown.
+	ErrUnsupportedEncoding error = scheme.Unsupported("prehash", "encoding", nil)
+)
+
+type prehashScheme struct {
+	inner    scheme.Scheme
+	digest   Digest
+	encoding Encoding
+	rand     io.Reader
+	// Returned by Hash if the source of randomness
+	// could not be passed to the inner scheme.
+	err error
+}
+
+// params are the prehash parameters of a hash.
+type params struct {
+	digest   Digest
+	encoding Encoding
+	// The HMAC key, nil for unkeyed hashes.
+	salt []byte
+}
+
+// New returns a Scheme which prehashes passwords with an HMAC using digest,
+// keyed by a random salt, and encoding before hashing them with inner.
+// Hashes using any digest and encoding are verified, and need an update
+// if they differ from the given ones or are unkeyed.
+func New(inner scheme.Scheme, digest Digest, encoding Encoding) scheme.Scheme {
+	s := &prehashScheme{
+		inner:    inner,
+		digest:   digest,
+		encoding: encoding,
+	}
+
+	if _, err := prehash(params{digest, encoding, nil}, nil); err != nil {
+		s.err = err
+	}
+
+	return s
+}
+
+func (s *prehashScheme) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.HashBytes(b)
+}
+
+func (s *prehashScheme) HashBytes(password []byte) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+
+	salt := make([]byte, SaltLength)
+	if err := scheme.ReadSalt("prehash", s.rand, salt); err != nil {
+		return "", err
+	}
+
+	p, err := prehash(params{s.digest, s.encoding, salt}, password)
+	if err != nil {
+		return "", err
+	}
+	defer scheme.Zero(p)
+
+	var h string
+	if bs, ok := s.inner.(scheme.BytesScheme); ok {
+		h, err = bs.HashBytes(p)
+	} else {
+		h, err = s.inner.Hash(string(p))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(h, "$") {
+		return "", scheme.Unsupported("prehash", "scheme", fmt.Errorf("%v does not use the modular crypt format", s.inner))
+	}
+
+	return fmt.Sprintf("%s%s%s-%s$%s%s", ident, keyed, s.digest, s.encoding, base64.RawStdEncoding.EncodeToString(salt), h), nil
+}
+
+func (s *prehashScheme) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return s.VerifyBytes(b, hash)
+}
+
+func (s *prehashScheme) VerifyBytes(password []byte, hash string) error {
+	params, stub, err := parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if !s.inner.SupportsStub(stub) {
+		return ErrInvalidStub
+	}
+
+	p, err := prehash(params, password)
+	if err != nil {
+		return err
+	}
+	defer scheme.Zero(p)
+
+	if bs, ok := s.inner.(scheme.BytesScheme); ok {
+		return bs.VerifyBytes(p, stub)
+	}
+
+	return s.inner.Verify(string(p), stub)
+}
+
+func (s *prehashScheme) SupportsStub(stub string) bool {
+	params, inner, err := parse(stub)
+	if err != nil {
+		return false
+	}
+
+	if _, err := prehash(params, nil); err != nil {
+		return false
+	}
+
+	return s.inner.SupportsStub(inner)
+}
+
+func (s *prehashScheme) NeedsUpdate(stub string) bool {
+	return len(s.UpdateReasons(stub)) != 0
+}
+
+func (s *prehashScheme) Params(stub string) (map[string]string, error) {
+	p, inner, err := parse(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{}
+	if d, ok := s.inner.(scheme.Describer); ok {
+		if params, err = d.Params(inner); err != nil {
+			return nil, err
+		}
+	}
+
+	params["digest"] = string(p.digest)
+	params["encoding"] = string(p.encoding)
+	params["keyed"] = fmt.Sprint(p.salt != nil)
+
+	return params, nil
+}
+
+func (s *prehashScheme) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	p, inner, err := parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if p.salt == nil || p.digest != s.digest || p.encoding != s.encoding {
+		reasons = append(reasons, scheme.ReasonVariant)
+	}
+
+	if len(p.salt) != 0 && len(p.salt) < SaltLength {
+		reasons = append(reasons, scheme.ReasonSalt)
+	}
+
+	if d, ok := s.inner.(scheme.Describer); ok {
+		reasons = append(reasons, d.UpdateReasons(inner)...)
+	} else if s.inner.NeedsUpdate(inner) {
+		reasons = append(reasons, scheme.ReasonDeprecated)
+	}
+
+	return
+}
+
+func (s *prehashScheme) CheckLimits(stub string, limits scheme.Limits) error {
+	_, inner, err := parse(stub)
+	if err != nil {
+		return err
+	}
+
+	if l, ok := s.inner.(scheme.Limiter); ok {
+		return l.CheckLimits(inner, limits)
+	}
+
+	return nil
+}
+
+// WithRand uses r for the salt and passes it to the inner scheme.
+func (s *prehashScheme) WithRand(r io.Reader) scheme.Scheme {
+	cc := *s
+	cc.rand = r
+	inner, err := scheme.WithRand(s.inner, r)
+	if err != nil {
+		cc.err = err
+	} else {
+		cc.inner = inner
+	}
+
+	return &cc
+}
+
+// WithLimits passes limits to the inner scheme, if it supports them.
+func (s *prehashScheme) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *s
+	if inner, err := scheme.WithLimits(s.inner, limits); err == nil {
+		cc.inner = inner
+	}
+
+	return &cc
+}
+
+func (s *prehashScheme) String() string {
+	return fmt.Sprintf("prehash-%s%s-%s(%v)", keyed, s.digest, s.encoding, s.inner)
+}
+
+// prehash returns the encoded digest of password, which the caller should zero.
+// The digest is an HMAC keyed by the salt, or a plain digest if the salt is nil.
+func prehash(p params, password []byte) ([]byte, error) {
+	var hf func() hash.Hash
+	switch p.digest {
+	case SHA256:
+		hf = sha256.New
+	case SHA512:
+		hf = sha512.New
+	case BLAKE2b:
+		hf = func() hash.Hash {
+			h, _ := blake2b.New512(nil)
+			return h
+		}
+	default:
+		return nil, ErrUnsupportedDigest
+	}
+
+	var h hash.Hash
+	if p.salt != nil {
+		h = hmac.New(hf, p.salt)
+	} else {
+		h = hf()
+	}
+
+	h.Write(password)
+	sum := h.Sum(nil)
+	defer scheme.Zero(sum)
+
+	var b []byte
+	switch p.encoding {
+	case Base64:
+		b = make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+		base64.StdEncoding.Encode(b, sum)
+	case Hex:
+		b = make([]byte, hex.EncodedLen(len(sum)))
+		hex.Encode(b, sum)
+	default:
+		return nil, ErrUnsupportedEncoding
+	}
+
+	return b, nil
+}
+
+// parse splits a stub into its prehash parameters and the stub of the inner scheme.
+func parse(stub string) (p params, inner string, err error) {
+	if !strings.HasPrefix(stub, ident) {
+		return params{}, "", ErrInvalidStub
+	}
+
+	name, rest, ok := strings.Cut(stub[len(ident):], "$")
+	if !ok {
+		return params{}, "", ErrInvalidStub
+	}
+
+	inner = "$" + rest
+	if name, ok = strings.CutPrefix(name, keyed); ok {
+		salt, rest, ok := strings.Cut(rest, "$")
+		if !ok {
+			return params{}, "", ErrInvalidStub
+		}
+
+		if p.salt, err = base64.RawStdEncoding.DecodeString(salt); err != nil || len(p.salt) == 0 {
+			return params{}, "", ErrInvalidStub
+		}
+
+		inner = "$" + rest
+	}
+
+	d, e, ok := strings.Cut(name, "-")
+	if !ok || d == "" || e == "" {
+		return params{}, "", ErrInvalidStub
+	}
+
+	p.digest, p.encoding = Digest(d), Encoding(e)
+
+	return p, inner, nil
+}
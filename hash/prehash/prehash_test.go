@@ -0,0 +1,117 @@
+package prehash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pchchv/pass/hash/bcrypt"
+	"github.com/pchchv/pass/hash/sha2"
+	"github.com/pchchv/pass/scheme"
+)
+
+type test struct {
+	inner    scheme.Scheme
+	password string
+	hash     string
+}
+
+var long = strings.Repeat("correct horse battery staple ", 10)
+
+// Computed with the crypt function of libxcrypt on the encoded digests.
+var tests = []test{
+	{bcrypt.New(4), "password", "$prehash-hmac-sha256-b64$MDEyMzQ1Njc4OWFiY2RlZg$2b$04$abcdefghijklmnopqrstuuGn.snEGYZ2WY4sxUGtlk5sFlQL0igK2"},
+	// Unkeyed.
+	{bcrypt.Crypter, "password", "$prehash-sha256-b64$2b$04$abcdefghijklmnopqrstuuNjBgm1f5PAJarK9STLtnoQTHtx3RGk."},
+	{sha2.Crypter512, long, "$prehash-sha512-hex$6$rounds=1000$saltsaltsalt$27HJYdjgIx2y6ZrJBBD5k5Y1jFXv5QY5HKgK7z/tt1t/0cpw3OP9oWKTfZs7kvjj/t5w5F5R6KNO5KIzEinw2."},
+}
+
+func TestKnownHashes(t *testing.T) {
+	for _, test := range tests {
+		s := New(test.inner, SHA256, Base64)
+		if !s.SupportsStub(test.hash) {
+			t.Errorf("scheme reports not supporting %s", test.hash)
+		}
+
+		if err := s.Verify(test.password, test.hash); err != nil {
+			t.Errorf("unable to verify %s: %v", test.hash, err)
+		}
+
+		if err := s.Verify(test.password+"x", test.hash); err == nil {
+			t.Errorf("invalid password accepted for %s", test.hash)
+		}
+
+		if keyed := strings.HasPrefix(test.hash, "$prehash-hmac-"); s.NeedsUpdate(test.hash) == keyed {
+			t.Errorf("unexpected NeedsUpdate result for %s", test.hash)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	for _, digest := range []Digest{SHA256, SHA512, BLAKE2b} {
+		for _, encoding := range []Encoding{Base64, Hex} {
+			s := New(sha2.NewCrypter512(1000), digest, encoding)
+			hash, err := s.Hash(long)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			prefix := "$prehash-hmac-" + string(digest) + "-" + string(encoding) + "$"
+			if !strings.HasPrefix(hash, prefix) || !strings.Contains(hash, "$6$rounds=1000$") {
+				t.Errorf("unexpected hash format %s", hash)
+			}
+
+			if err := s.Verify(long, hash); err != nil {
+				t.Errorf("valid password not accepted: %v", err)
+			}
+
+			if s.NeedsUpdate(hash) {
+				t.Errorf("hash %s needs an update", hash)
+			}
+
+			if !New(sha2.NewCrypter512(1000), SHA256, Base64).NeedsUpdate(hash) && (digest != SHA256 || encoding != Base64) {
+				t.Errorf("hash with another digest does not need an update")
+			}
+		}
+	}
+
+	// The prehash lifts the length limit of bcrypt.
+	s := New(bcrypt.NewWithPolicy(4, bcrypt.TruncateError), SHA256, Base64)
+	hash, err := s.Hash(long)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := s.Verify(long[:72], hash); !errors.Is(err, scheme.ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch, got %v", err)
+	}
+
+	if _, err := New(sha2.Crypter512, "md5", Base64).Hash("password"); err != ErrUnsupportedDigest {
+		t.Errorf("expected ErrUnsupportedDigest, got %v", err)
+	}
+
+	if _, err := New(sha2.Crypter512, SHA256, "base32").Hash("password"); err != ErrUnsupportedEncoding {
+		t.Errorf("expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	s := New(sha2.Crypter512, SHA256, Base64)
+	for _, stub := range []string{
+		"$prehash-",
+		"$prehash-sha256$6$rounds=1000$saltsaltsalt",
+		"$prehash-md5-b64$6$rounds=1000$saltsaltsalt",
+		"$prehash-sha256-b64$5$rounds=1000$saltsaltsalt",
+		"$prehash-hmac-sha256-b64$6$rounds=1000$saltsaltsalt",
+		"$prehash-hmac-sha256-b64$!!$6$rounds=1000$saltsaltsalt",
+		"$6$rounds=1000$saltsaltsalt",
+	} {
+		if s.SupportsStub(stub) {
+			t.Errorf("invalid stub supported: %s", stub)
+		}
+
+		if err := s.Verify("password", stub); !errors.Is(err, scheme.ErrMalformedHash) && !errors.Is(err, scheme.ErrUnsupportedParameter) {
+			t.Errorf("expected ErrMalformedHash or ErrUnsupportedParameter for %s, got %v", stub, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package raw
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add("foobar", "$s2$16384$8$1$qa9lVfhmTE8F2Jpwya9m7uoE$Q7dSPqhZQCLWpjniaz7RVm+xorpSAPTvOCP2uoZmoiI=")
+	f.Add("foobar", "$s2$3$1$1$c2FsdA==")
+	f.Add("foobar", "$s2$0$0$0$$")
+	f.Add("foobar", "$s2$x$y$z")
+
+	f.Fuzz(func(t *testing.T, password, stub string) {
+		salt, _, N, r, p, err := Parse(stub)
+		if err != nil || N > 1024 || r*p > 16 {
+			return
+		}
+
+		ScryptSHA256(password, salt, N, r, p)
+	})
+}
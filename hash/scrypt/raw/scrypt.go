@@ -4,10 +4,12 @@ package raw
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/pchchv/pass/scheme"
 	"golang.org/x/crypto/scrypt"
 )
 
@@ -20,7 +22,10 @@ const (
 	Recommendedp = 1
 )
 
-var ErrInvalidStub = fmt.Errorf("invalid scrypt password stub")
+var (
+	ErrInvalidStub   error = scheme.Malformed("scrypt", "", nil)
+	ErrInvalidParams error = scheme.Unsupported("scrypt", "", errors.New("N, r and p must be positive"))
+)
 
 // Wrapper for golang.org/x/crypto/scrypt that implements a sensible modular crypt interface.
 //
@@ -28,18 +33,38 @@ var ErrInvalidStub = fmt.Errorf("invalid scrypt password stub")
 // salt must be a random salt value in binary form.
 // N, r, and p are parameters for scrypt.
 //
-// Returns a modular crypt hash.
-func ScryptSHA256(password string, salt []byte, N, r, p int) string {
-	passwordb := []byte(password)
-	hash, err := scrypt.Key(passwordb, salt, N, r, p, 32)
-	if err != nil {
-		panic(err)
+// Returns a modular crypt hash, or an error if the parameters are invalid.
+func ScryptSHA256(password string, salt []byte, N, r, p int) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return ScryptSHA256Bytes(b, salt, N, r, p)
+}
+
+// ScryptSHA256Bytes is like ScryptSHA256, but takes the password as a byte slice.
+func ScryptSHA256Bytes(password, salt []byte, N, r, p int) (string, error) {
+	hash, err := Key(password, salt, N, r, p, 32)
+	if err == ErrInvalidParams {
+		return "", err
+	} else if err != nil {
+		return "", scheme.Unsupported("scrypt", "N", err)
 	}
 
 	strHash := base64.StdEncoding.EncodeToString(hash)
 	strSalt := base64.StdEncoding.EncodeToString(salt)
 
-	return fmt.Sprintf("$s2$%d$%d$%d$%s$%s", N, r, p, strSalt, strHash)
+	return fmt.Sprintf("$s2$%d$%d$%d$%s$%s", N, r, p, strSalt, strHash), nil
+}
+
+// Key derives a raw scrypt key of keyLen bytes.
+// It is used by formats which encode the key differently from $s2$.
+func Key(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	// scrypt.Key divides by r and p.
+	if r < 1 || p < 1 {
+		return nil, ErrInvalidParams
+	}
+
+	return scrypt.Key(password, salt, N, r, p, keyLen)
 }
 
 // Parse parses a scrypt modular hash or stub string.
@@ -65,28 +90,38 @@ func Parse(stub string) (salt, hash []byte, N, r, p int, err error) {
 
 	Ni, err = strconv.ParseUint(parts[0], 10, 31)
 	if err != nil {
+		err = scheme.Malformed("scrypt", "N", err)
 		return
 	}
 
 	ri, err = strconv.ParseUint(parts[1], 10, 31)
 	if err != nil {
+		err = scheme.Malformed("scrypt", "r", err)
 		return
 	}
 
 	pi, err = strconv.ParseUint(parts[2], 10, 31)
 	if err != nil {
+		err = scheme.Malformed("scrypt", "p", err)
 		return
 	}
 
 	N, r, p = int(Ni), int(ri), int(pi)
+	if N < 1 || r < 1 || p < 1 {
+		err = ErrInvalidParams
+		return
+	}
 
 	salt, err = base64.StdEncoding.DecodeString(parts[3])
 	if err != nil {
+		err = scheme.Malformed("scrypt", "salt", err)
 		return
 	}
 
 	if len(parts) >= 5 {
-		hash, err = base64.StdEncoding.DecodeString(parts[4])
+		if hash, err = base64.StdEncoding.DecodeString(parts[4]); err != nil {
+			err = scheme.Malformed("scrypt", "hash", err)
+		}
 	}
 
 	return
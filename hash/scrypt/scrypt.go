@@ -3,10 +3,11 @@
 package scrypt
 
 import (
-	"crypto/rand"
 	"encoding/base64"
 	"expvar"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/pchchv/pass/hash/scrypt/raw"
@@ -20,30 +21,79 @@ var (
 )
 
 type scryptSHA256Crypter struct {
-	nN int
-	r  int
-	p  int
+	nN       int
+	r        int
+	p        int
+	rand     io.Reader
+	ceilings *scheme.Limits
 }
 
 func init() {
-	SHA256Crypter = NewSHA256(
-		raw.RecommendedN,
-		raw.Recommendedr,
-		raw.Recommendedp,
-	)
+	SHA256Crypter = NewSHA256WithOptions()
+}
+
+// Option configures a scheme created by NewSHA256WithOptions.
+type Option func(*scryptSHA256Crypter)
+
+// WithN sets the CPU/memory cost parameter N.
+func WithN(N int) Option {
+	return func(c *scryptSHA256Crypter) { c.nN = N }
+}
+
+// WithR sets the block size parameter r.
+func WithR(r int) Option {
+	return func(c *scryptSHA256Crypter) { c.r = r }
+}
+
+// WithP sets the parallelization parameter p.
+func WithP(p int) Option {
+	return func(c *scryptSHA256Crypter) { c.p = p }
+}
+
+// WithRand sets the source of randomness for salts.
+// If not set, crypto/rand.Reader is used.
+func WithRand(r io.Reader) Option {
+	return func(c *scryptSHA256Crypter) { c.rand = r }
+}
+
+// WithLimits sets the ceilings enforced when verifying a hash.
+// If not set, scheme.DefaultLimits is used.
+// They are raised as needed to verify the hashes the scheme produces.
+func WithLimits(limits scheme.Limits) Option {
+	return func(c *scryptSHA256Crypter) { c.ceilings = &limits }
 }
 
 // Returns an implementation of Scheme implementing
 // scrypt-sha256 with the specified parameters.
 func NewSHA256(N, r, p int) scheme.Scheme {
-	return &scryptSHA256Crypter{
-		nN: N,
-		r:  r,
-		p:  p,
+	return NewSHA256WithOptions(WithN(N), WithR(r), WithP(p))
+}
+
+// NewSHA256WithOptions returns an implementation of Scheme implementing scrypt-sha256.
+// Parameters which are not set by an option use the recommended values from raw.
+// The returned scheme is immutable and safe for concurrent use.
+func NewSHA256WithOptions(opts ...Option) scheme.Scheme {
+	c := &scryptSHA256Crypter{
+		nN: raw.RecommendedN,
+		r:  raw.Recommendedr,
+		p:  raw.Recommendedp,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+func (c *scryptSHA256Crypter) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.HashBytes(b)
 }
 
-func (c *scryptSHA256Crypter) Hash(password string) (hash string, err error) {
+func (c *scryptSHA256Crypter) HashBytes(password []byte) (hash string, err error) {
 	cScryptSHA256HashCalls.Add(1)
 	stub, err := c.makeStub()
 	if err != nil {
@@ -55,7 +105,14 @@ func (c *scryptSHA256Crypter) Hash(password string) (hash string, err error) {
 	return
 }
 
-func (c *scryptSHA256Crypter) Verify(password, hash string) (err error) {
+func (c *scryptSHA256Crypter) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.VerifyBytes(b, hash)
+}
+
+func (c *scryptSHA256Crypter) VerifyBytes(password []byte, hash string) (err error) {
 	cScryptSHA256VerifyCalls.Add(1)
 	_, newHash, _, _, _, _, err := c.hash(password, hash)
 	if err == nil && !scheme.SecureCompare(hash, newHash) {
@@ -65,38 +122,106 @@ func (c *scryptSHA256Crypter) Verify(password, hash string) (err error) {
 	return
 }
 
-func (c *scryptSHA256Crypter) SetParams(N, r, p int) error {
-	c.nN = N
-	c.r = r
-	c.p = p
+func (c *scryptSHA256Crypter) CheckLimits(stub string, limits scheme.Limits) error {
+	_, _, N, r, p, err := raw.Parse(stub)
+	if err != nil {
+		return err
+	}
+
+	return checkLimits(N, r, p, limits)
+}
+
+// limits returns the configured or default limits, raised to allow the configured parameters.
+func (c *scryptSHA256Crypter) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if c.ceilings != nil {
+		limits = *c.ceilings
+	}
+
+	if m := scryptMemory(c.nN, c.r); limits.MaxMemory != 0 && m > limits.MaxMemory {
+		limits.MaxMemory = m
+	}
+
+	if limits.MaxPasses != 0 && c.p > limits.MaxPasses {
+		limits.MaxPasses = c.p
+	}
+
+	return limits
+}
+
+func checkLimits(N, r, p int, limits scheme.Limits) error {
+	if err := limits.CheckMemory("scrypt", "N", uint64(N), 128*uint64(r)); err != nil {
+		return err
+	}
 
-	return nil
+	return limits.CheckPasses("scrypt", "p", p)
+}
+
+// scryptMemory returns the approximate memory in bytes used by scrypt.
+func scryptMemory(N, r int) uint64 {
+	return 128 * uint64(N) * uint64(r)
 }
 
 func (c *scryptSHA256Crypter) SupportsStub(stub string) bool {
 	return strings.HasPrefix(stub, "$s2$")
 }
 
+func (c *scryptSHA256Crypter) WithRand(r io.Reader) scheme.Scheme {
+	cc := *c
+	cc.rand = r
+
+	return &cc
+}
+
+func (c *scryptSHA256Crypter) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *c
+	cc.ceilings = &limits
+
+	return &cc
+}
+
 func (c *scryptSHA256Crypter) String() string {
 	return fmt.Sprintf("scrypt-sha256(%d,%d,%d)", c.nN, c.r, c.p)
 }
 
 func (c *scryptSHA256Crypter) NeedsUpdate(stub string) bool {
+	return len(c.UpdateReasons(stub)) != 0
+}
+
+func (c *scryptSHA256Crypter) Params(stub string) (map[string]string, error) {
 	salt, _, N, r, p, err := raw.Parse(stub)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return c.needsUpdate(salt, N, r, p)
+	return map[string]string{
+		"N":           strconv.Itoa(N),
+		"r":           strconv.Itoa(r),
+		"p":           strconv.Itoa(p),
+		"salt_length": strconv.Itoa(len(salt)),
+	}, nil
 }
 
-func (c *scryptSHA256Crypter) needsUpdate(salt []byte, N, r, p int) bool {
-	return len(salt) < 18 || N < c.nN || r < c.r || p < c.p
+func (c *scryptSHA256Crypter) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	salt, _, N, r, p, err := raw.Parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if N < c.nN || r < c.r || p < c.p {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if len(salt) < 18 {
+		reasons = append(reasons, scheme.ReasonSalt)
+	}
+
+	return
 }
 
 func (c *scryptSHA256Crypter) makeStub() (string, error) {
 	buf := make([]byte, 18)
-	if _, err := rand.Read(buf); err != nil {
+	if err := scheme.ReadSalt("scrypt", c.rand, buf); err != nil {
 		return "", err
 	}
 
@@ -105,11 +230,16 @@ func (c *scryptSHA256Crypter) makeStub() (string, error) {
 	return fmt.Sprintf("$s2$%d$%d$%d$%s", c.nN, c.r, c.p, salt), nil
 }
 
-func (c *scryptSHA256Crypter) hash(password, stub string) (oldHashRaw []byte, newHash string, salt []byte, N, r, p int, err error) {
+func (c *scryptSHA256Crypter) hash(password []byte, stub string) (oldHashRaw []byte, newHash string, salt []byte, N, r, p int, err error) {
 	salt, oldHashRaw, N, r, p, err = raw.Parse(stub)
 	if err != nil {
 		return
 	}
 
-	return oldHashRaw, raw.ScryptSHA256(password, salt, N, r, p), salt, N, r, p, nil
+	if err = checkLimits(N, r, p, c.limits()); err != nil {
+		return
+	}
+
+	newHash, err = raw.ScryptSHA256Bytes(password, salt, N, r, p)
+	return oldHashRaw, newHash, salt, N, r, p, err
 }
@@ -0,0 +1,27 @@
+package raw
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add("", "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZF1z4.jnz")
+	f.Add("Hello world!", "$6$rounds=10000$saltstringsaltstring$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.")
+	f.Add("x", "$5$rounds=99999999999999999999$a$")
+	f.Add("x", "$6$$$$")
+
+	f.Fuzz(func(t *testing.T, password, stub string) {
+		isSHA512, salt, _, rounds, err := Parse(stub)
+		if err != nil || rounds > 5000 {
+			return
+		}
+
+		if isSHA512 {
+			_, err = Crypt512(password, salt, rounds)
+		} else {
+			_, err = Crypt256(password, salt, rounds)
+		}
+
+		if err != nil && err != ErrInvalidSalt {
+			t.Errorf("Crypt(%q, %q, %d): %v", password, salt, rounds, err)
+		}
+	})
+}
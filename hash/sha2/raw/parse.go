@@ -4,15 +4,22 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+
+	"github.com/pchchv/pass/scheme"
 )
 
 var (
-	ErrInvalidStub   = errors.New("invalid stub")
-	ErrInvalidRounds = errors.New("invalid number of rounds")
+	ErrInvalidStub   error = scheme.Malformed("sha-crypt", "", nil)
+	ErrInvalidSalt   error = scheme.Unsupported("sha-crypt", "salt", nil)
+	ErrInvalidRounds error = scheme.Unsupported("sha-crypt", "rounds", nil)
 )
 
 // Parse scans a modular sha256-crypt or sha512-crypt or
 // modular cryptohash to determine configuration parameters.
+//
+// Like glibc, Parse clamps out-of-range rounds values into
+// MinimumRounds <= rounds <= MaximumRounds and truncates
+// salts to MaxSaltLength characters.
 func Parse(stub string) (isSHA512 bool, salt, hash string, rounds int, err error) {
 	// $5$
 	if len(stub) < 3 || stub[0] != '$' || stub[2] != '$' {
@@ -63,21 +70,76 @@ func Parse(stub string) (isSHA512 bool, salt, hash string, rounds int, err error
 
 		roundsStr = roundsStr[7:]
 		var n uint64
-		n, err = strconv.ParseUint(roundsStr, 10, 31)
+		n, err = strconv.ParseUint(roundsStr, 10, 64)
 		if err != nil {
-			err = ErrInvalidStub
-			return
-		}
+			if !errors.Is(err, strconv.ErrRange) {
+				err = ErrInvalidStub
+				return
+			}
 
-		rounds = int(n)
+			n, err = MaximumRounds, nil
+		}
 
-		if rounds < MinimumRounds || rounds > MaximumRounds {
-			err = ErrInvalidRounds
-			return
+		if n < MinimumRounds {
+			n = MinimumRounds
+		} else if n > MaximumRounds {
+			n = MaximumRounds
 		}
+
+		rounds = int(n)
 	} else {
 		rounds = DefaultRounds
 	}
 
+	if len(salt) > MaxSaltLength {
+		salt = salt[:MaxSaltLength]
+	}
+
+	return
+}
+
+// ParseSetting parses a sha256-crypt or sha512-crypt setting
+// the way crypt(3) from glibc does, for use by crypt-compatible APIs.
+// Unlike Parse, it never fails after the "$5$" or "$6$" prefix:
+// a field starting with "rounds=" is only used as the rounds if a
+// number and a "$" follow, the salt ends at the next "$" and anything
+// after it is ignored. explicit reports whether the setting gave the
+// rounds, in which case crypt(3) includes them in its output.
+func ParseSetting(setting string) (isSHA512 bool, salt string, rounds int, explicit bool, err error) {
+	if len(setting) < 3 || setting[0] != '$' || (setting[1] != '5' && setting[1] != '6') || setting[2] != '$' {
+		err = ErrInvalidStub
+		return
+	}
+
+	isSHA512 = setting[1] == '6'
+	rest := setting[3:]
+	rounds = DefaultRounds
+
+	if num, ok := strings.CutPrefix(rest, "rounds="); ok {
+		// Like strtoul, which saturates on overflow.
+		i := 0
+		var n uint64
+		for ; i < len(num) && num[i] >= '0' && num[i] <= '9'; i++ {
+			if n < MaximumRounds {
+				n = n*10 + uint64(num[i]-'0')
+			}
+		}
+
+		if i < len(num) && num[i] == '$' {
+			if n < MinimumRounds {
+				n = MinimumRounds
+			} else if n > MaximumRounds {
+				n = MaximumRounds
+			}
+
+			rounds, explicit, rest = int(n), true, num[i+1:]
+		}
+	}
+
+	salt, _, _ = strings.Cut(rest, "$")
+	if len(salt) > MaxSaltLength {
+		salt = salt[:MaxSaltLength]
+	}
+
 	return
 }
@@ -2,11 +2,15 @@
 package raw
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/sha512"
 	"fmt"
 	"hash"
 	"io"
+	"strings"
+
+	"github.com/pchchv/pass/scheme"
 )
 
 const (
@@ -23,6 +27,9 @@ const (
 	// It is recommended to call sha256-crypt or
 	// sha512-crypt with this or a proportional value.
 	RecommendedRounds = 10000
+	// Maximum length of the salt for sha256-crypt and sha512-crypt.
+	// Longer salts are truncated by Parse, as glibc does.
+	MaxSaltLength = 16
 )
 
 func repeat(w io.Writer, b []byte, sz int) {
@@ -35,6 +42,29 @@ func repeat(w io.Writer, b []byte, sz int) {
 	w.Write(b[0 : sz-i])
 }
 
+// repeatN writes b to w n times, batching the writes
+// to reduce the per-call overhead for short inputs.
+func repeatN(w io.Writer, b []byte, n int) {
+	if len(b) == 0 || n == 0 {
+		return
+	}
+
+	const batchSize = 4096
+	k := batchSize/len(b) + 1
+	if k > n {
+		k = n
+	}
+
+	batch := bytes.Repeat(b, k)
+	defer scheme.Zero(batch)
+
+	for ; n >= k; n -= k {
+		w.Write(batch)
+	}
+
+	w.Write(batch[:n*len(b)])
+}
+
 func repeatTo(out []byte, b []byte) {
 	if len(b) == 0 {
 		return
@@ -49,23 +79,24 @@ func repeatTo(out []byte, b []byte) {
 	copy(out[i:], b)
 }
 
-func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, transpose func(b []byte)) string {
+func shaCrypt(passwordb []byte, salt string, rounds int, newHash func() hash.Hash, transpose func(b []byte)) (string, error) {
 	if rounds < MinimumRounds || rounds > MaximumRounds {
-		panic("sha256-crypt rounds must be in 1000 <= rounds <= 999999999")
+		return "", ErrInvalidRounds
 	}
 
-	passwordb := []byte(password)
-	saltb := []byte(salt)
-	if len(saltb) > 16 {
-		panic("salt must not exceed 16 bytes")
+	if len(salt) > MaxSaltLength || strings.ContainsAny(salt, "$\x00\n") {
+		return "", ErrInvalidSalt
 	}
 
+	saltb := []byte(salt)
+
 	// B
 	b := newHash()
 	b.Write(passwordb)
 	b.Write(saltb)
 	b.Write(passwordb)
 	bsum := b.Sum(nil)
+	defer scheme.Zero(bsum)
 
 	// A
 	a := newHash()
@@ -84,17 +115,22 @@ func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, trans
 	}
 
 	asum := a.Sum(nil)
+	defer scheme.Zero(asum)
 
 	// DP
+	// The password is hashed len(password) times, so this step stays
+	// quadratic in the password length: the digest input itself is
+	// len(password)² bytes, and no compatible implementation can avoid it.
+	// pass.Context bounds the cost with MaxPasswordLength.
 	dp := newHash()
-	for i := 0; i < len(passwordb); i++ {
-		dp.Write(passwordb)
-	}
+	repeatN(dp, passwordb, len(passwordb))
 
 	dpsum := dp.Sum(nil)
+	defer scheme.Zero(dpsum)
 
 	// P
 	p := make([]byte, len(passwordb))
+	defer scheme.Zero(p)
 	repeatTo(p, dpsum)
 
 	// DS
@@ -107,12 +143,14 @@ func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, trans
 
 	// S
 	s := make([]byte, len(saltb))
+	defer scheme.Zero(s)
 	repeatTo(s, dssum)
 
 	// C
 	cur := asum[:]
+	c := newHash()
 	for i := 0; i < rounds; i++ {
-		c := newHash()
+		c.Reset()
 		if (i & 1) != 0 {
 			c.Write(p)
 		} else {
@@ -129,7 +167,8 @@ func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, trans
 		} else {
 			c.Write(cur)
 		}
-		cur = c.Sum(nil)[:]
+		// The previous digest has been written, so it can be overwritten.
+		cur = c.Sum(cur[:0])
 	}
 
 	// Transposition
@@ -139,40 +178,70 @@ func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, trans
 	hstr := EncodeBase64(cur)
 
 	if rounds == DefaultRounds {
-		return fmt.Sprintf("$%s$%s", salt, hstr)
+		return fmt.Sprintf("$%s$%s", salt, hstr), nil
 	}
 
-	return fmt.Sprintf("$rounds=%d$%s$%s", rounds, salt, hstr)
+	return fmt.Sprintf("$rounds=%d$%s$%s", rounds, salt, hstr), nil
 }
 
 // Calculates sha256-crypt.
 // The password must be plaintext and be a UTF-8 string.
-// Salt must be a valid ASCII between 0 and 16 characters in length inclusive.
+// Salt must be a valid ASCII between 0 and 16 characters in length inclusive,
+// and must not contain '$'. Otherwise, ErrInvalidSalt is returned.
 // For suggested values for rounds, see the constants in this package.
 // Rounds must be in the range 1000 <= rounds <= 999999999.
-// Otherwise, the function panics.
+// Otherwise, ErrInvalidRounds is returned.
 // The output is in modular crypt format.
-func Crypt256(password, salt string, rounds int) string {
-	return "$5" + shaCrypt(password, salt, rounds, sha256.New, transpose256)
+func Crypt256(password, salt string, rounds int) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return Crypt256Bytes(b, salt, rounds)
 }
 
-// Calculates sha256-crypt.
+// Like Crypt256, but takes the password as a byte slice.
+// Intermediate values derived from the password are zeroed.
+func Crypt256Bytes(password []byte, salt string, rounds int) (string, error) {
+	h, err := shaCrypt(password, salt, rounds, sha256.New, transpose256)
+	if err != nil {
+		return "", err
+	}
+
+	return "$5" + h, nil
+}
+
+// Calculates sha512-crypt.
 // The password must be plaintext and be a UTF-8 string.
-// Salt must be a valid ASCII between 0 and 16 characters in length inclusive.
+// Salt must be a valid ASCII between 0 and 16 characters in length inclusive,
+// and must not contain '$'. Otherwise, ErrInvalidSalt is returned.
 // For suggested values for rounds, see the constants in this package.
 // Rounds must be in the range 1000 <= rounds <= 999999999.
-// Otherwise, the function panics.
+// Otherwise, ErrInvalidRounds is returned.
 // The output is in modular crypt format.
-func Crypt512(password, salt string, rounds int) string {
-	return "$6" + shaCrypt(password, salt, rounds, sha512.New, transpose512)
+func Crypt512(password, salt string, rounds int) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return Crypt512Bytes(b, salt, rounds)
+}
+
+// Like Crypt512, but takes the password as a byte slice.
+// Intermediate values derived from the password are zeroed.
+func Crypt512Bytes(password []byte, salt string, rounds int) (string, error) {
+	h, err := shaCrypt(password, salt, rounds, sha512.New, transpose512)
+	if err != nil {
+		return "", err
+	}
+
+	return "$6" + h, nil
 }
 
 func transpose256(b []byte) {
-	b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15], b[16], b[17], b[18], b[19], b[20], b[21], b[22], b[23], b[24], b[25], b[26], b[27], b[28], b[29], b[30], b[31] =
-		b[20], b[10], b[0], b[11], b[1], b[21], b[2], b[22], b[12], b[23], b[13], b[3], b[14], b[4], b[24], b[5], b[25], b[15], b[26], b[16], b[6], b[17], b[7], b[27], b[8], b[28], b[18], b[29], b[19], b[9], b[30], b[31]
+	b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15], b[16], b[17], b[18], b[19], b[20], b[21], b[22], b[23], b[24], b[25], b[26], b[27], b[28], b[29] =
+		b[20], b[10], b[0], b[11], b[1], b[21], b[2], b[22], b[12], b[23], b[13], b[3], b[14], b[4], b[24], b[5], b[25], b[15], b[26], b[16], b[6], b[17], b[7], b[27], b[8], b[28], b[18], b[29], b[19], b[9]
 }
 
 func transpose512(b []byte) {
-	b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15], b[16], b[17], b[18], b[19], b[20], b[21], b[22], b[23], b[24], b[25], b[26], b[27], b[28], b[29], b[30], b[31], b[32], b[33], b[34], b[35], b[36], b[37], b[38], b[39], b[40], b[41], b[42], b[43], b[44], b[45], b[46], b[47], b[48], b[49], b[50], b[51], b[52], b[53], b[54], b[55], b[56], b[57], b[58], b[59], b[60], b[61], b[62], b[63] =
-		b[42], b[21], b[0], b[1], b[43], b[22], b[23], b[2], b[44], b[45], b[24], b[3], b[4], b[46], b[25], b[26], b[5], b[47], b[48], b[27], b[6], b[7], b[49], b[28], b[29], b[8], b[50], b[51], b[30], b[9], b[10], b[52], b[31], b[32], b[11], b[53], b[54], b[33], b[12], b[13], b[55], b[34], b[35], b[14], b[56], b[57], b[36], b[15], b[16], b[58], b[37], b[38], b[17], b[59], b[60], b[39], b[18], b[19], b[61], b[40], b[41], b[20], b[62], b[63]
+	b[0], b[1], b[2], b[3], b[4], b[5], b[6], b[7], b[8], b[9], b[10], b[11], b[12], b[13], b[14], b[15], b[16], b[17], b[18], b[19], b[20], b[21], b[22], b[23], b[24], b[25], b[26], b[27], b[28], b[29], b[30], b[31], b[32], b[33], b[34], b[35], b[36], b[37], b[38], b[39], b[40], b[41], b[42], b[43], b[44], b[45], b[46], b[47], b[48], b[49], b[50], b[51], b[52], b[53], b[54], b[55], b[56], b[57], b[58], b[59], b[60], b[61] =
+		b[42], b[21], b[0], b[1], b[43], b[22], b[23], b[2], b[44], b[45], b[24], b[3], b[4], b[46], b[25], b[26], b[5], b[47], b[48], b[27], b[6], b[7], b[49], b[28], b[29], b[8], b[50], b[51], b[30], b[9], b[10], b[52], b[31], b[32], b[11], b[53], b[54], b[33], b[12], b[13], b[55], b[34], b[35], b[14], b[56], b[57], b[36], b[15], b[16], b[58], b[37], b[38], b[17], b[59], b[60], b[39], b[18], b[19], b[61], b[40], b[41], b[20]
 }
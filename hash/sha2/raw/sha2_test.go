@@ -2,6 +2,7 @@ package raw
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -212,10 +213,10 @@ var (
 func TestSHA256Crypt(t *testing.T) {
 	for i, tst := range tests {
 		fmt.Printf("%d\n", i)
-		out := Crypt256(tst.password, tst.salt, tst.rounds)
-		if out != tst.output {
-			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n  salt: %#v\n  rounds: %#v\n",
-				out, tst.output, tst.password, tst.salt, tst.rounds)
+		out, err := Crypt256(tst.password, tst.salt, tst.rounds)
+		if err != nil || out != tst.output {
+			t.Errorf("mismatch:\n  got: %#v (%v)\n  expected: %#v\n  password: %#v\n  salt: %#v\n  rounds: %#v\n",
+				out, err, tst.output, tst.password, tst.salt, tst.rounds)
 		}
 	}
 }
@@ -223,10 +224,63 @@ func TestSHA256Crypt(t *testing.T) {
 func TestSHA512Crypt(t *testing.T) {
 	for i, tst := range tests512 {
 		fmt.Printf("%d\n", i)
-		out := Crypt512(tst.password, tst.salt, tst.rounds)
-		if out != tst.output {
-			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n  salt: %#v\n  rounds: %#v\n",
-				out, tst.output, tst.password, tst.salt, tst.rounds)
+		out, err := Crypt512(tst.password, tst.salt, tst.rounds)
+		if err != nil || out != tst.output {
+			t.Errorf("mismatch:\n  got: %#v (%v)\n  expected: %#v\n  password: %#v\n  salt: %#v\n  rounds: %#v\n",
+				out, err, tst.output, tst.password, tst.salt, tst.rounds)
+		}
+	}
+}
+
+func TestLongPassword(t *testing.T) {
+	// Generated with libxcrypt.
+	const expected = "$6$rounds=1000$saltsalt$0N5AmNkEC2dNnOcscb9BZOEjO.zjGlHeQS7DHlaOx2qj0M/o9b399m34Cx.VUCysfRijO9L/jyUHu2y8v7laz."
+	if out, err := Crypt512(strings.Repeat("x", 200), "saltsalt", 1000); err != nil || out != expected {
+		t.Errorf("got %v (%v), expected %v", out, err, expected)
+	}
+}
+
+func TestCryptInvalidInput(t *testing.T) {
+	for _, tst := range []struct {
+		salt   string
+		rounds int
+		err    error
+	}{
+		{"a", MinimumRounds - 1, ErrInvalidRounds},
+		{"a", MaximumRounds + 1, ErrInvalidRounds},
+		{"abcdefghijklmnopq", DefaultRounds, ErrInvalidSalt},
+		{"ab$c", DefaultRounds, ErrInvalidSalt},
+	} {
+		if _, err := Crypt256("password", tst.salt, tst.rounds); err != tst.err {
+			t.Errorf("Crypt256(%q, %d): got %v, expected %v", tst.salt, tst.rounds, err, tst.err)
+		}
+
+		if _, err := Crypt512("password", tst.salt, tst.rounds); err != tst.err {
+			t.Errorf("Crypt512(%q, %d): got %v, expected %v", tst.salt, tst.rounds, err, tst.err)
+		}
+	}
+}
+
+func TestParseGlibcCompat(t *testing.T) {
+	for _, tst := range []struct {
+		stub   string
+		salt   string
+		rounds int
+	}{
+		{"$5$rounds=10$salt", "salt", MinimumRounds},
+		{"$5$rounds=1000000000$salt", "salt", MaximumRounds},
+		{"$5$rounds=99999999999999999999999$salt", "salt", MaximumRounds},
+		{"$6$abcdefghijklmnopqrstuvwxyz$hash", "abcdefghijklmnop", DefaultRounds},
+	} {
+		_, salt, _, rounds, err := Parse(tst.stub)
+		if err != nil || salt != tst.salt || rounds != tst.rounds {
+			t.Errorf("Parse(%q): got %q, %d, %v", tst.stub, salt, rounds, err)
+		}
+	}
+
+	for _, stub := range []string{"$5$rounds=$salt", "$5$rounds=-1$salt", "$5$rounds=1e4$salt"} {
+		if _, _, _, _, err := Parse(stub); err != ErrInvalidStub {
+			t.Errorf("Parse(%q): got %v, expected %v", stub, err, ErrInvalidStub)
 		}
 	}
 }
@@ -2,16 +2,22 @@
 package sha2
 
 import (
-	"crypto/rand"
 	"expvar"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/pchchv/pass/hash/sha2/raw"
 	"github.com/pchchv/pass/scheme"
 )
 
+// The recommended salt length for sha256-crypt and sha512-crypt.
+// This is the maximum length supported by the format.
+const RecommendedSaltLength = raw.MaxSaltLength
+
 var (
-	errInvalidStub        = fmt.Errorf("invalid sha2 password stub")
+	errInvalidStub        = scheme.Malformed("sha-crypt", "ident", nil)
 	cSHA2CryptHashCalls   = expvar.NewInt("passlib.sha2crypt.hashCalls")
 	cSHA2CryptVerifyCalls = expvar.NewInt("passlib.sha2crypt.verifyCalls")
 	// An implementation of Scheme performing sha256-crypt.
@@ -21,28 +27,110 @@ var (
 )
 
 type sha2Crypter struct {
-	sha512 bool
-	rounds int
+	sha512     bool
+	rounds     int
+	saltLength int
+	rand       io.Reader
+	ceilings   *scheme.Limits
 }
 
 func init() {
-	Crypter256 = NewCrypter256(raw.RecommendedRounds)
-	Crypter512 = NewCrypter512(raw.RecommendedRounds)
+	Crypter256 = NewCrypter256WithOptions()
+	Crypter512 = NewCrypter512WithOptions()
+}
+
+// Option configures a scheme created by
+// NewCrypter256WithOptions or NewCrypter512WithOptions.
+type Option func(*sha2Crypter)
+
+// WithRounds sets the number of rounds.
+func WithRounds(rounds int) Option {
+	return func(c *sha2Crypter) { c.rounds = rounds }
+}
+
+// WithSaltLength sets the salt length, which must be in
+// the range 1 <= saltLength <= 16, otherwise Hash returns raw.ErrInvalidSalt.
+// Hashes with a shorter salt need an update.
+func WithSaltLength(saltLength int) Option {
+	return func(c *sha2Crypter) { c.saltLength = saltLength }
+}
+
+// WithRand sets the source of randomness for salts.
+// If not set, crypto/rand.Reader is used.
+func WithRand(r io.Reader) Option {
+	return func(c *sha2Crypter) { c.rand = r }
+}
+
+// WithLimits sets the ceilings enforced when verifying a hash.
+// If not set, scheme.DefaultLimits is used.
+// They are raised as needed to verify the hashes the scheme produces.
+func WithLimits(limits scheme.Limits) Option {
+	return func(c *sha2Crypter) { c.ceilings = &limits }
+}
+
+// Returns a Scheme implementing sha256-crypt.
+// Parameters which are not set by an option use
+// raw.RecommendedRounds and RecommendedSaltLength.
+// The returned scheme is immutable and safe for concurrent use.
+func NewCrypter256WithOptions(opts ...Option) scheme.Scheme {
+	return newCrypter(false, opts)
+}
+
+// Returns a Scheme implementing sha512-crypt.
+// Parameters which are not set by an option use
+// raw.RecommendedRounds and RecommendedSaltLength.
+// The returned scheme is immutable and safe for concurrent use.
+func NewCrypter512WithOptions(opts ...Option) scheme.Scheme {
+	return newCrypter(true, opts)
+}
+
+func newCrypter(sha512 bool, opts []Option) scheme.Scheme {
+	c := &sha2Crypter{sha512: sha512, rounds: raw.RecommendedRounds, saltLength: RecommendedSaltLength}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // Returns a Scheme implementing sha256-crypt
 // using the number of rounds specified.
 func NewCrypter256(rounds int) scheme.Scheme {
-	return &sha2Crypter{false, rounds}
+	return NewCrypter256WithOptions(WithRounds(rounds))
 }
 
 // Returns a Scheme implementing sha512-crypt
 // using the number of rounds specified.
 func NewCrypter512(rounds int) scheme.Scheme {
-	return &sha2Crypter{true, rounds}
+	return NewCrypter512WithOptions(WithRounds(rounds))
 }
 
-func (c *sha2Crypter) Hash(password string) (hash string, err error) {
+// Returns a Scheme implementing sha256-crypt
+// using the number of rounds and salt length specified.
+// The salt length must be in the range 1 <= saltLength <= 16,
+// otherwise Hash returns raw.ErrInvalidSalt.
+// Hashes with a shorter salt need an update.
+func NewCrypter256WithSaltLength(rounds, saltLength int) scheme.Scheme {
+	return NewCrypter256WithOptions(WithRounds(rounds), WithSaltLength(saltLength))
+}
+
+// Returns a Scheme implementing sha512-crypt
+// using the number of rounds and salt length specified.
+// The salt length must be in the range 1 <= saltLength <= 16,
+// otherwise Hash returns raw.ErrInvalidSalt.
+// Hashes with a shorter salt need an update.
+func NewCrypter512WithSaltLength(rounds, saltLength int) scheme.Scheme {
+	return NewCrypter512WithOptions(WithRounds(rounds), WithSaltLength(saltLength))
+}
+
+func (c *sha2Crypter) Hash(password string) (string, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.HashBytes(b)
+}
+
+func (c *sha2Crypter) HashBytes(password []byte) (hash string, err error) {
 	cSHA2CryptHashCalls.Add(1)
 
 	stub, err := c.makeStub()
@@ -55,27 +143,65 @@ func (c *sha2Crypter) Hash(password string) (hash string, err error) {
 	return
 }
 
-func (c *sha2Crypter) Verify(password, hash string) (err error) {
+func (c *sha2Crypter) Verify(password, hash string) error {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return c.VerifyBytes(b, hash)
+}
+
+func (c *sha2Crypter) VerifyBytes(password []byte, hash string) (err error) {
 	cSHA2CryptVerifyCalls.Add(1)
 
-	_, newHash, _, _, err := c.hash(password, hash)
-	if err == nil && !scheme.SecureCompare(hash, newHash) {
+	oldHash, newHash, _, _, err := c.hash(password, hash)
+	if err != nil {
+		return err
+	}
+
+	// Only compare the hash itself, as the rounds and salt of the
+	// recomputed hash may have been normalized by raw.Parse.
+	if !scheme.SecureCompare(oldHash, newHash[strings.LastIndexByte(newHash, '$')+1:]) {
 		return scheme.ErrInvalidPassword
 	}
 
-	return
+	return nil
 }
 
-// Changes the default rounds for the crypter.
-// Be warned that this is a global setting.
-// The default default value is RecommendedRounds.
-func (c *sha2Crypter) SetRounds(rounds int) error {
-	if rounds < raw.MinimumRounds || rounds > raw.MaximumRounds {
-		return raw.ErrInvalidRounds
+func (c *sha2Crypter) CheckLimits(stub string, limits scheme.Limits) error {
+	_, _, _, rounds, err := raw.Parse(stub)
+	if err != nil {
+		return err
 	}
 
-	c.rounds = rounds
-	return nil
+	return limits.CheckRounds("sha-crypt", "rounds", rounds)
+}
+
+// limits returns the configured or default limits, raised to allow the configured rounds.
+func (c *sha2Crypter) limits() scheme.Limits {
+	limits := scheme.DefaultLimits()
+	if c.ceilings != nil {
+		limits = *c.ceilings
+	}
+
+	if limits.MaxRounds != 0 && c.rounds > limits.MaxRounds {
+		limits.MaxRounds = c.rounds
+	}
+
+	return limits
+}
+
+func (c *sha2Crypter) WithRand(r io.Reader) scheme.Scheme {
+	cc := *c
+	cc.rand = r
+
+	return &cc
+}
+
+func (c *sha2Crypter) WithLimits(limits scheme.Limits) scheme.Scheme {
+	cc := *c
+	cc.ceilings = &limits
+
+	return &cc
 }
 
 func (c *sha2Crypter) SupportsStub(stub string) bool {
@@ -87,20 +213,44 @@ func (c *sha2Crypter) SupportsStub(stub string) bool {
 }
 
 func (c *sha2Crypter) NeedsUpdate(stub string) bool {
+	return len(c.UpdateReasons(stub)) != 0
+}
+
+func (c *sha2Crypter) Params(stub string) (map[string]string, error) {
 	_, salt, _, rounds, err := raw.Parse(stub)
 	if err != nil {
-		return false
+		return nil, err
 	}
 
-	return c.needsUpdate(salt, rounds)
+	return map[string]string{
+		"rounds":      strconv.Itoa(rounds),
+		"salt_length": strconv.Itoa(len(salt)),
+	}, nil
+}
+
+func (c *sha2Crypter) UpdateReasons(stub string) (reasons []scheme.UpdateReason) {
+	_, salt, _, rounds, err := raw.Parse(stub)
+	if err != nil {
+		return nil
+	}
+
+	if rounds < c.rounds {
+		reasons = append(reasons, scheme.ReasonCost)
+	}
+
+	if len(salt) < c.saltLength {
+		reasons = append(reasons, scheme.ReasonSalt)
+	}
+
+	return
 }
 
 func (c *sha2Crypter) String() string {
 	if c.sha512 {
-		return fmt.Sprintf("sha512-crypt(%d)", c.rounds)
+		return fmt.Sprintf("sha512-crypt(%d,%d)", c.rounds, c.saltLength)
 	}
 
-	return fmt.Sprintf("sha256-crypt(%d)", c.rounds)
+	return fmt.Sprintf("sha256-crypt(%d,%d)", c.rounds, c.saltLength)
 }
 
 func (c *sha2Crypter) makeStub() (string, error) {
@@ -109,13 +259,16 @@ func (c *sha2Crypter) makeStub() (string, error) {
 		ch = "6"
 	}
 
+	if c.saltLength < 1 || c.saltLength > raw.MaxSaltLength {
+		return "", raw.ErrInvalidSalt
+	}
+
 	buf := make([]byte, 12)
-	_, err := rand.Read(buf)
-	if err != nil {
+	if err := scheme.ReadSalt("sha-crypt", c.rand, buf); err != nil {
 		return "", err
 	}
 
-	salt := raw.EncodeBase64(buf)[0:16]
+	salt := raw.EncodeBase64(buf)[0:c.saltLength]
 
 	if c.rounds == raw.DefaultRounds {
 		return fmt.Sprintf("$%s$%s", ch, salt), nil
@@ -124,7 +277,7 @@ func (c *sha2Crypter) makeStub() (string, error) {
 	return fmt.Sprintf("$%s$rounds=%d$%s", ch, c.rounds, salt), nil
 }
 
-func (c *sha2Crypter) hash(password, stub string) (oldHash, newHash, salt string, rounds int, err error) {
+func (c *sha2Crypter) hash(password []byte, stub string) (oldHash, newHash, salt string, rounds int, err error) {
 	isSHA512, salt, oldHash, rounds, err := raw.Parse(stub)
 	if err != nil {
 		return "", "", "", 0, err
@@ -134,13 +287,15 @@ func (c *sha2Crypter) hash(password, stub string) (oldHash, newHash, salt string
 		return "", "", "", 0, errInvalidStub
 	}
 
-	if c.sha512 {
-		return oldHash, raw.Crypt512(password, salt, rounds), salt, rounds, nil
+	if err = c.limits().CheckRounds("sha-crypt", "rounds", rounds); err != nil {
+		return "", "", "", 0, err
 	}
 
-	return oldHash, raw.Crypt256(password, salt, rounds), salt, rounds, nil
-}
+	if c.sha512 {
+		newHash, err = raw.Crypt512Bytes(password, salt, rounds)
+	} else {
+		newHash, err = raw.Crypt256Bytes(password, salt, rounds)
+	}
 
-func (c *sha2Crypter) needsUpdate(salt string, rounds int) bool {
-	return rounds < c.rounds || len(salt) < 16
+	return oldHash, newHash, salt, rounds, err
 }
@@ -0,0 +1,75 @@
+package sha2
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/hash/sha2/raw"
+)
+
+func TestSaltLength(t *testing.T) {
+	crypter := NewCrypter512WithSaltLength(raw.MinimumRounds, 8)
+
+	hash, err := crypter.Hash("password")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	_, salt, _, _, err := raw.Parse(hash)
+	if err != nil || len(salt) != 8 {
+		t.Errorf("unexpected salt %q in %s: %v", salt, hash, err)
+	}
+
+	if err := crypter.Verify("password", hash); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if crypter.NeedsUpdate(hash) {
+		t.Errorf("hash with configured salt length needs an update")
+	}
+
+	if !Crypter512.NeedsUpdate(hash) {
+		t.Errorf("hash with short salt does not need an update")
+	}
+
+	for _, n := range []int{0, raw.MaxSaltLength + 1} {
+		if _, err := NewCrypter256WithSaltLength(raw.MinimumRounds, n).Hash("password"); err != raw.ErrInvalidSalt {
+			t.Errorf("salt length %d: got %v, expected %v", n, err, raw.ErrInvalidSalt)
+		}
+	}
+}
+
+func TestVerifyClampedRounds(t *testing.T) {
+	// Hashes from glibc systems may contain out-of-range rounds,
+	// which glibc clamps into the supported range.
+	hash, err := raw.Crypt256("password", "salt", raw.MinimumRounds)
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	stub := "$5$rounds=10$salt$" + hash[len("$5$rounds=1000$salt$"):]
+	if err := Crypter256.Verify("password", stub); err != nil {
+		t.Errorf("hash with clamped rounds not accepted: %v", err)
+	}
+
+	if err := Crypter256.Verify("wrong", stub); err == nil {
+		t.Errorf("invalid password accepted")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	crypter := NewCrypter256WithOptions(WithRounds(raw.MinimumRounds))
+
+	hash, err := crypter.Hash("password")
+	if err != nil {
+		t.Fatalf("recieved error whilst hashing password: %v", err)
+	}
+
+	_, salt, _, rounds, err := raw.Parse(hash)
+	if err != nil || rounds != raw.MinimumRounds || len(salt) != RecommendedSaltLength {
+		t.Errorf("unexpected parameters in %s: %v", hash, err)
+	}
+
+	if !Crypter256.NeedsUpdate(hash) || crypter.NeedsUpdate(hash) {
+		t.Errorf("unexpected NeedsUpdate result for %s", hash)
+	}
+}
@@ -0,0 +1,178 @@
+package pass
+
+import (
+	"bytes"
+	"errors"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pchchv/pass/scheme"
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization is a Unicode normalization applied to passwords
+// before they are hashed or verified, so that the same password
+// typed on different systems produces the same hash.
+type Normalization int
+
+const (
+	NormalizeNone     Normalization = iota // Passwords are used as given.
+	NormalizeNFC                           // Unicode normalization form C.
+	NormalizeNFKC                          // Unicode normalization form KC.
+	NormalizeSASLprep                      // SASLprep (RFC 4013), as in Python's passlib.
+)
+
+// Returned when a password is not valid UTF-8 or, for SASLprep,
+// contains prohibited characters or violates the bidi rules.
+// It is of kind scheme.ErrPolicyRejected.
+var ErrInvalidPasswordCharacters error = scheme.Rejected("", "password", errors.New("contains invalid characters"))
+
+// normalize returns the normalized form of password.
+// The result may share memory with password.
+// Intermediate buffers are zeroed.
+func (n Normalization) normalize(password []byte) ([]byte, error) {
+	if n == NormalizeNone {
+		return password, nil
+	}
+
+	if !utf8.Valid(password) {
+		return nil, ErrInvalidPasswordCharacters
+	}
+
+	switch n {
+	case NormalizeNFC:
+		return norm.NFC.Bytes(password), nil
+	case NormalizeNFKC:
+		return norm.NFKC.Bytes(password), nil
+	case NormalizeSASLprep:
+		return saslprep(password)
+	}
+
+	return password, nil
+}
+
+// saslprep implements the SASLprep profile of stringprep for stored strings.
+// Unassigned code points are those unassigned in the Unicode version
+// of package unicode, rather than in Unicode 3.2 as in RFC 3454.
+func saslprep(password []byte) ([]byte, error) {
+	// Mapping (RFC 4013 section 2.1).
+	mapped := bytes.Map(func(r rune) rune {
+		switch {
+		case inTable(r, mappedToNothing):
+			return -1
+		case inTable(r, nonASCIISpace):
+			return ' '
+		}
+
+		return r
+	}, password)
+
+	// Normalization (section 2.2).
+	s := norm.NFKC.Bytes(mapped)
+	if !sameBuffer(s, mapped) {
+		scheme.Zero(mapped)
+	}
+
+	if err := checkSASLprep(s); err != nil {
+		scheme.Zero(s)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// checkSASLprep checks a mapped and normalized password
+// for prohibited and unassigned code points and the bidi rules.
+func checkSASLprep(s []byte) error {
+	// Prohibited output and unassigned code points (sections 2.3 and 2.5).
+	var hasRandAL, hasL bool
+	for _, r := range string(s) {
+		if inTable(r, nonASCIISpace) || inTable(r, prohibited) || !isAssigned(r) {
+			return ErrInvalidPasswordCharacters
+		}
+
+		switch p, _ := bidi.LookupRune(r); p.Class() {
+		case bidi.R, bidi.AL:
+			hasRandAL = true
+		case bidi.L:
+			hasL = true
+		}
+	}
+
+	// Bidirectional characters (section 2.4, RFC 3454 section 6).
+	if hasRandAL {
+		first, _ := utf8.DecodeRune(s)
+		last, _ := utf8.DecodeLastRune(s)
+		if hasL || !isRandAL(first) || !isRandAL(last) {
+			return ErrInvalidPasswordCharacters
+		}
+	}
+
+	return nil
+}
+
+// sameBuffer reports whether a and b share their first byte.
+func sameBuffer(a, b []byte) bool {
+	return len(a) != 0 && len(b) != 0 && &a[0] == &b[0]
+}
+
+func isRandAL(r rune) bool {
+	p, _ := bidi.LookupRune(r)
+	return p.Class() == bidi.R || p.Class() == bidi.AL
+}
+
+func isAssigned(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.C)
+}
+
+// inTable reports whether r is in a table of inclusive ranges.
+func inTable(r rune, table [][2]rune) bool {
+	for _, rng := range table {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RFC 3454 table B.1.
+var mappedToNothing = [][2]rune{
+	{0x00AD, 0x00AD}, {0x034F, 0x034F}, {0x1806, 0x1806}, {0x180B, 0x180D},
+	{0x200B, 0x200D}, {0x2060, 0x2060}, {0xFE00, 0xFE0F}, {0xFEFF, 0xFEFF},
+}
+
+// RFC 3454 table C.1.2.
+var nonASCIISpace = [][2]rune{
+	{0x00A0, 0x00A0}, {0x1680, 0x1680}, {0x2000, 0x200B}, {0x202F, 0x202F},
+	{0x205F, 0x205F}, {0x3000, 0x3000},
+}
+
+// RFC 3454 tables C.2.1, C.2.2 and C.3 to C.9.
+var prohibited = [][2]rune{
+	// C.2.1 ASCII control characters.
+	{0x0000, 0x001F}, {0x007F, 0x007F},
+	// C.2.2 Non-ASCII control characters.
+	{0x0080, 0x009F}, {0x06DD, 0x06DD}, {0x070F, 0x070F}, {0x180E, 0x180E},
+	{0x200C, 0x200D}, {0x2028, 0x2029}, {0x2060, 0x2063}, {0x206A, 0x206F},
+	{0xFEFF, 0xFEFF}, {0xFFF9, 0xFFFC}, {0x1D173, 0x1D17A},
+	// C.3 Private use.
+	{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD},
+	// C.4 Non-character code points.
+	{0xFDD0, 0xFDEF}, {0xFFFE, 0xFFFF}, {0x1FFFE, 0x1FFFF}, {0x2FFFE, 0x2FFFF},
+	{0x3FFFE, 0x3FFFF}, {0x4FFFE, 0x4FFFF}, {0x5FFFE, 0x5FFFF}, {0x6FFFE, 0x6FFFF},
+	{0x7FFFE, 0x7FFFF}, {0x8FFFE, 0x8FFFF}, {0x9FFFE, 0x9FFFF}, {0xAFFFE, 0xAFFFF},
+	{0xBFFFE, 0xBFFFF}, {0xCFFFE, 0xCFFFF}, {0xDFFFE, 0xDFFFF}, {0xEFFFE, 0xEFFFF},
+	{0xFFFFE, 0xFFFFF}, {0x10FFFE, 0x10FFFF},
+	// C.5 Surrogate codes.
+	{0xD800, 0xDFFF},
+	// C.6 Inappropriate for plain text.
+	{0xFFF9, 0xFFFD},
+	// C.7 Inappropriate for canonical representation.
+	{0x2FF0, 0x2FFB},
+	// C.8 Change display properties or are deprecated.
+	{0x0340, 0x0341}, {0x200E, 0x200F}, {0x202A, 0x202E}, {0x206A, 0x206F},
+	// C.9 Tagging characters.
+	{0xE0001, 0xE0001}, {0xE0020, 0xE007F},
+}
@@ -0,0 +1,73 @@
+package pass
+
+import (
+	"testing"
+
+	"github.com/pchchv/pass/hash/sha2"
+	"github.com/pchchv/pass/scheme"
+)
+
+func TestSASLprep(t *testing.T) {
+	// Examples from RFC 4013 section 3.
+	for _, test := range []struct {
+		input, output string
+		valid         bool
+	}{
+		{"I\u00adX", "IX", true},
+		{"user", "user", true},
+		{"USER", "USER", true},
+		{"\u00aa", "a", true},
+		{"\u2168", "IX", true},
+		{"\u0007", "", false},
+		{"\u06271", "", false},
+		{"a\u00a0b", "a b", true},
+		{"\u06271\u0628", "\u06271\u0628", true},
+		{"\xff", "", false},
+	} {
+		b, err := NormalizeSASLprep.normalize([]byte(test.input))
+		output := string(b)
+		if test.valid && (err != nil || output != test.output) {
+			t.Errorf("SASLprep(%q) = %q, %v, expected %q", test.input, output, err, test.output)
+		} else if !test.valid && err != ErrInvalidPasswordCharacters {
+			t.Errorf("SASLprep(%q) = %q, %v, expected ErrInvalidPasswordCharacters", test.input, output, err)
+		}
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	const nfc, nfd = "caf\u00e9", "cafe\u0301"
+
+	c := Context{Schemes: []scheme.Scheme{sha2.NewCrypter256(1000)}, Normalization: NormalizeNFC}
+	h, err := c.Hash(nfd)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if newHash, err := c.Verify(nfc, h); err != nil || newHash != "" {
+		t.Errorf("unexpected result verifying NFC form: %q, %v", newHash, err)
+	}
+
+	// A hash of the unnormalized password, created before normalization was enabled.
+	old, err := (&Context{Schemes: c.Schemes}).Hash(nfd)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := c.Verify(nfd, old); err != scheme.ErrPasswordMismatch {
+		t.Errorf("expected mismatch without fallback, got %v", err)
+	}
+
+	c.VerifyUnnormalized = true
+	res, err := c.VerifyDetailed(nfd, old)
+	if err != nil {
+		t.Fatalf("err verifying with fallback: %v", err)
+	}
+
+	if len(res.Reasons) != 1 || res.Reasons[0] != scheme.ReasonNormalized || res.NewHash == "" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	if newHash, err := c.Verify(nfc, res.NewHash); err != nil || newHash != "" {
+		t.Errorf("unexpected result verifying upgraded hash: %q, %v", newHash, err)
+	}
+}
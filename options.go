@@ -0,0 +1,92 @@
+package pass
+
+import (
+	"io"
+
+	"github.com/pchchv/pass/scheme"
+)
+
+// Option configures a context created by Context.With.
+type Option func(*Context)
+
+// WithSchemes sets the schemes of the context, the most preferred ones first.
+func WithSchemes(schemes ...scheme.Scheme) Option {
+	return func(ctx *Context) {
+		ctx.Schemes = append([]scheme.Scheme(nil), schemes...)
+	}
+}
+
+// WithLimits sets the ceilings on the resources used to verify a hash.
+func WithLimits(limits scheme.Limits) Option {
+	return func(ctx *Context) { ctx.Limits = &limits }
+}
+
+// WithMaxPasswordLength sets the maximum length of passwords in bytes.
+// See Context.MaxPasswordLength.
+func WithMaxPasswordLength(max int) Option {
+	return func(ctx *Context) { ctx.MaxPasswordLength = max }
+}
+
+// WithNormalization sets the Unicode normalization applied to passwords.
+func WithNormalization(n Normalization) Option {
+	return func(ctx *Context) { ctx.Normalization = n }
+}
+
+// WithVerifyUnnormalized sets whether passwords are also verified as given.
+// See Context.VerifyUnnormalized.
+func WithVerifyUnnormalized(verify bool) Option {
+	return func(ctx *Context) { ctx.VerifyUnnormalized = verify }
+}
+
+// WithBinding sets whether hashes are bound to their owner.
+// See Context.Binding.
+func WithBinding(binding Binding) Option {
+	return func(ctx *Context) { ctx.Binding = binding }
+}
+
+// WithRand sets the source of randomness for the salts of new hashes.
+// See Context.Rand.
+func WithRand(r io.Reader) Option {
+	return func(ctx *Context) { ctx.Rand = r }
+}
+
+// WithCategory sets the context used for a category of users.
+// See Context.Categories.
+func WithCategory(name string, category *Context) Option {
+	return func(ctx *Context) {
+		if ctx.Categories == nil {
+			ctx.Categories = map[string]*Context{}
+		}
+
+		ctx.Categories[name] = category
+	}
+}
+
+// With returns a copy of the context with the options applied.
+// The context itself is not modified, so a context can be
+// derived from one in concurrent use, such as Default().
+// The copy does not share the schemes slice, limits or categories map of the context.
+func (ctx *Context) With(opts ...Option) *Context {
+	c := *ctx
+	if ctx.Schemes != nil {
+		c.Schemes = append([]scheme.Scheme(nil), ctx.Schemes...)
+	}
+
+	if ctx.Limits != nil {
+		limits := *ctx.Limits
+		c.Limits = &limits
+	}
+
+	if ctx.Categories != nil {
+		c.Categories = make(map[string]*Context, len(ctx.Categories))
+		for name, category := range ctx.Categories {
+			c.Categories[name] = category
+		}
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
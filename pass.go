@@ -8,12 +8,32 @@
 package pass
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"sync/atomic"
+
 	"github.com/pchchv/pass/scheme"
 )
 
-// The default context, which uses sensible defaults.
-// Most users should not reconfigure this.
-var DefaultContext Context
+// The default maximum length of passwords in bytes.
+// See Context.MaxPasswordLength.
+const DefaultMaxPasswordLength = 4096
+
+var (
+	// The default context, which uses sensible defaults.
+	// Most users should not reconfigure this.
+	// Modifying it is not safe while passwords are being hashed
+	// or verified; use SetDefault to reconfigure at runtime.
+	DefaultContext Context
+
+	// The context installed by SetDefault, if any.
+	defaultContext atomic.Pointer[Context]
+
+	// Returned when a password exceeds the maximum length of a context.
+	// It is of kind scheme.ErrPolicyRejected.
+	ErrPasswordTooLong error = scheme.Rejected("", "password", errors.New("exceeds the maximum length"))
+)
 
 // Context is a password hashing context that uses a
 // given set of schemes to hash and validate passwords.
@@ -23,6 +43,51 @@ type Context struct {
 	// A hash update will be issued every time a password is
 	// validated using a scheme that is not the first in this slice.
 	Schemes []scheme.Scheme
+
+	// Limits are ceilings on the resources used to verify a hash,
+	// checked before running the key derivation function.
+	// If nil, only the ceilings of the schemes themselves apply,
+	// which default to scheme.DefaultLimits and can be set
+	// per scheme with scheme.WithLimits.
+	Limits *scheme.Limits
+
+	// The maximum length of passwords in bytes.
+	// Longer passwords are rejected with ErrPasswordTooLong
+	// before hashing, as some schemes take time quadratic
+	// in the password length.
+	// If zero, DefaultMaxPasswordLength is used.
+	// If negative, the length is not limited.
+	MaxPasswordLength int
+
+	// The Unicode normalization applied to passwords
+	// before hashing and verifying them.
+	Normalization Normalization
+
+	// If true, passwords which do not match in normalized form are
+	// also verified as given, to accept hashes created before
+	// normalization was enabled. Such hashes are upgraded.
+	VerifyUnnormalized bool
+
+	// The source of randomness for the salts of new hashes.
+	// It must be safe for concurrent use if the context is.
+	// If nil, each scheme uses its own source, crypto/rand.Reader by default.
+	// If set, hashing fails with scheme.ErrRandUnsupported
+	// if the preferred scheme does not implement scheme.RandSetter.
+	Rand io.Reader
+
+	// Policies for categories of users, such as "admin" or "service",
+	// used by Category, HashFor, VerifyFor and NeedsUpdateFor.
+	// A category overrides the fields it sets and inherits the zero ones
+	// from this context, such as Binding, Normalization and Limits. Hashes which the schemes of a category do not support
+	// are verified using the schemes of this context and upgraded, so that
+	// a user moved to a category with a stronger policy gets a new hash at
+	// the next login. Unknown categories use this context.
+	Categories map[string]*Context
+
+	// Whether hashes are bound to their owner, see Binding.
+	// Unless it is BindNone, Hash and Verify fail with ErrOwnerRequired
+	// and HashBound and VerifyBound must be used instead.
+	Binding Binding
 }
 
 // Hashes a UTF-8 plaintext password using the context and produces a password hash.
@@ -33,7 +98,46 @@ type Context struct {
 // If the context has not been specifically configured, a sensible default policy is used.
 // See the fields of Context.
 func (ctx *Context) Hash(password string) (hash string, err error) {
-	return ctx.schemes()[0].Hash(password)
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return ctx.HashBytes(b)
+}
+
+// Like Hash, but takes the password as a byte slice, which the caller
+// can zero after use. The password is not modified or retained,
+// and the copies made while hashing are zeroed.
+func (ctx *Context) HashBytes(password []byte) (hash string, err error) {
+	return ctx.hash(password, nil)
+}
+
+// hash normalizes and hashes the password,
+// binding the hash to owner unless it is nil.
+func (ctx *Context) hash(password, owner []byte) (hash string, err error) {
+	if owner == nil && ctx.Binding != BindNone {
+		return "", ErrOwnerRequired
+	}
+
+	if password, err = ctx.normalize(password); err != nil {
+		return "", err
+	}
+	defer scheme.Zero(password)
+
+	return ctx.hashPreferred(password, owner)
+}
+
+// VerifyResult describes a successful password verification.
+type VerifyResult struct {
+	// The scheme which verified the hash.
+	Scheme scheme.Scheme
+	// The parameters parsed from the hash.
+	// Nil if the scheme does not implement scheme.Describer.
+	Params map[string]string
+	// The reasons why the hash needs an update, empty if it does not.
+	Reasons []scheme.UpdateReason
+	// The hash upgraded according to the context policy.
+	// Empty if no upgrade is required or rehashing failed.
+	NewHash string
 }
 
 // Verifies a UTF-8 plaintext password using a previously derived password hash and the default context.
@@ -45,22 +149,130 @@ func (ctx *Context) Hash(password string) (hash string, err error) {
 // newHash is empty if the password was not valid or if no upgrade is required.
 // You should treat any non-nil err as a password verification error.
 func (ctx *Context) Verify(password, hash string) (newHash string, err error) {
-	return ctx.verify(password, hash, true)
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return ctx.VerifyBytes(b, hash)
+}
+
+// Like Verify, but takes the password as a byte slice, which the caller
+// can zero after use. The password is not modified or retained,
+// and the copies made while verifying are zeroed.
+func (ctx *Context) VerifyBytes(password []byte, hash string) (newHash string, err error) {
+	res, err := ctx.verify(password, hash, nil, true)
+	if err != nil {
+		return "", err
+	}
+
+	return res.NewHash, nil
 }
 
 // Like Verify, but does not hash an upgrade password when upgrade is required.
 func (ctx *Context) VerifyNoUpgrade(password, hash string) (err error) {
-	_, err = ctx.verify(password, hash, false)
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	_, err = ctx.verify(b, hash, nil, false)
 	return
 }
 
+// Like Verify, but describes which scheme verified the hash
+// and why it was upgraded, for example for audit logs.
+// The result is nil if err is not nil.
+func (ctx *Context) VerifyDetailed(password, hash string) (*VerifyResult, error) {
+	b := []byte(password)
+	defer scheme.Zero(b)
+
+	return ctx.verify(b, hash, nil, true)
+}
+
+// Like Hash, but uses the policy of the given category.
+// See Context.Categories.
+func (ctx *Context) HashFor(category, password string) (hash string, err error) {
+	return ctx.Category(category).Hash(password)
+}
+
+// Like Verify, but uses the policy of the given category.
+// See Context.Categories.
+func (ctx *Context) VerifyFor(category, password, hash string) (newHash string, err error) {
+	return ctx.Category(category).Verify(password, hash)
+}
+
+// Like NeedsUpdate, but uses the policy of the given category.
+// See Context.Categories.
+func (ctx *Context) NeedsUpdateFor(category, stub string) bool {
+	return ctx.Category(category).NeedsUpdate(stub)
+}
+
+// Category returns the effective context of a category of users:
+// the fields set by the category applied over this context, with the
+// schemes of this context appended to verify hashes created under
+// another category. It returns ctx for unknown categories.
+// Use it for the methods without a category variant, such as VerifyBound.
+// See Context.Categories.
+func (ctx *Context) Category(name string) *Context {
+	c, ok := ctx.Categories[name]
+	if !ok || c == ctx {
+		return ctx
+	}
+
+	schemes := append([]scheme.Scheme(nil), c.Schemes...)
+	for _, s := range ctx.schemes() {
+		found := false
+		for _, cs := range schemes {
+			found = found || cs == s
+		}
+
+		if !found {
+			schemes = append(schemes, s)
+		}
+	}
+
+	merged := ctx.With(WithSchemes(schemes...))
+	merged.Categories = nil
+	if c.Limits != nil {
+		limits := *c.Limits
+		merged.Limits = &limits
+	}
+
+	if c.MaxPasswordLength != 0 {
+		merged.MaxPasswordLength = c.MaxPasswordLength
+	}
+
+	if c.Normalization != NormalizeNone {
+		merged.Normalization = c.Normalization
+	}
+
+	if c.VerifyUnnormalized {
+		merged.VerifyUnnormalized = true
+	}
+
+	if c.Rand != nil {
+		merged.Rand = c.Rand
+	}
+
+	if c.Binding != BindNone {
+		merged.Binding = c.Binding
+	}
+
+	return merged
+}
+
 // Determines whether a stub or hash needs updating
 // according to the policy of the context.
+// Hashes of disabled accounts (see scheme.Disabler) never need updating.
 func (ctx *Context) NeedsUpdate(stub string) bool {
-	for i, scheme := range ctx.schemes() {
-		if scheme.SupportsStub(stub) {
-			return i != 0 || scheme.NeedsUpdate(stub)
+	stub, bound := unbind(stub)
+	for i, s := range ctx.schemes() {
+		if !s.SupportsStub(stub) {
+			continue
+		}
+
+		if d, ok := s.(scheme.Disabler); ok && d.Disabled(stub) {
+			return false
 		}
+
+		return i != 0 || (!bound && ctx.Binding != BindNone) || s.NeedsUpdate(stub)
 	}
 
 	return false
@@ -74,31 +286,229 @@ func (ctx *Context) schemes() []scheme.Scheme {
 	return ctx.Schemes
 }
 
-func (ctx *Context) verify(password, hash string, canUpgrade bool) (newHash string, err error) {
-	for i, scheme := range ctx.schemes() {
-		if !scheme.SupportsStub(hash) {
+// verify normalizes and verifies the password,
+// checking that the hash is bound to owner unless it is nil.
+func (ctx *Context) verify(password []byte, hash string, owner []byte, canUpgrade bool) (*VerifyResult, error) {
+	if owner == nil && ctx.Binding != BindNone {
+		return nil, ErrOwnerRequired
+	}
+
+	normalized, err := ctx.normalize(password)
+	if err == nil {
+		res, err := ctx.verifyNormalized(normalized, hash, owner, canUpgrade)
+		changed := !bytes.Equal(normalized, password)
+		scheme.Zero(normalized)
+		if !ctx.VerifyUnnormalized || !changed || !errors.Is(err, scheme.ErrPasswordMismatch) {
+			return res, err
+		}
+	} else if !ctx.VerifyUnnormalized || errors.Is(err, ErrPasswordTooLong) {
+		return nil, err
+	}
+
+	// Fall back to hashes of the password as given.
+	res, err := ctx.verifyNormalized(password, hash, owner, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Reasons = append(res.Reasons, scheme.ReasonNormalized)
+	if canUpgrade {
+		// Fails if the password cannot be normalized.
+		if newHash, err := ctx.hash(password, owner); err == nil {
+			res.NewHash = newHash
+		}
+	}
+
+	return res, nil
+}
+
+// verifyNormalized verifies an already normalized password.
+func (ctx *Context) verifyNormalized(password []byte, hash string, owner []byte, canUpgrade bool) (*VerifyResult, error) {
+	// The password verified by the scheme, which differs for bound hashes.
+	key := password
+	var reasons []scheme.UpdateReason
+	hash, bound := unbind(hash)
+	switch {
+	case bound && owner == nil:
+		return nil, ErrOwnerRequired
+	case bound:
+		key = bind(owner, password)
+		defer scheme.Zero(key)
+	case owner != nil && ctx.Binding == BindRequire:
+		return nil, ErrUnbound
+	case owner != nil:
+		reasons = append(reasons, scheme.ReasonUnbound)
+	}
+
+	for i, s := range ctx.schemes() {
+		if !s.SupportsStub(hash) {
 			continue
 		}
 
-		err = scheme.Verify(password, hash)
+		if l, ok := s.(scheme.Limiter); ok && ctx.Limits != nil {
+			if err := l.CheckLimits(hash, *ctx.Limits); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		truncated := false
+		if t, ok := s.(scheme.Truncater); ok {
+			truncated, err = t.VerifyTruncated(key, hash)
+		} else {
+			err = verifyBytes(s, key, hash)
+		}
+
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
-		if i != 0 || scheme.NeedsUpdate(hash) {
-			if canUpgrade {
-				// If the scheme is not the first scheme, try and rehash with the
-				// preferred scheme.
-				if newHash, err2 := ctx.Hash(password); err2 == nil {
-					return newHash, nil
-				}
+		res := &VerifyResult{Scheme: s, Reasons: reasons}
+		if d, ok := s.(scheme.Describer); ok {
+			res.Params, _ = d.Params(hash)
+		}
+
+		if i != 0 {
+			res.Reasons = append(res.Reasons, scheme.ReasonNotPreferred)
+		}
+
+		res.Reasons = append(res.Reasons, updateReasons(s, hash)...)
+
+		// A hash which only matched a truncated password is upgraded,
+		// unless the preferred scheme would truncate it again.
+		if truncated && (owner != nil || !ctx.truncates(password)) {
+			res.Reasons = append(res.Reasons, scheme.ReasonTruncated)
+		}
+
+		if canUpgrade && len(res.Reasons) != 0 {
+			// Try and rehash with the preferred scheme.
+			if newHash, err := ctx.hashPreferred(password, owner); err == nil {
+				res.NewHash = newHash
 			}
 		}
 
-		return "", nil
+		return res, nil
 	}
 
-	return "", scheme.ErrUnsupportedScheme
+	return nil, scheme.ErrUnsupportedScheme
+}
+
+// updateReasons returns why the scheme considers the hash outdated.
+func updateReasons(s scheme.Scheme, hash string) []scheme.UpdateReason {
+	if d, ok := s.(scheme.Describer); ok {
+		return d.UpdateReasons(hash)
+	}
+
+	if s.NeedsUpdate(hash) {
+		return []scheme.UpdateReason{scheme.ReasonDeprecated}
+	}
+
+	return nil
+}
+
+// hashPreferred hashes an already normalized password
+// using the preferred scheme and the source of randomness of the context,
+// binding the hash to owner unless it is nil.
+func (ctx *Context) hashPreferred(password, owner []byte) (string, error) {
+	s := ctx.schemes()[0]
+	if ctx.Rand != nil {
+		var err error
+		if s, err = scheme.WithRand(s, ctx.Rand); err != nil {
+			return "", err
+		}
+	}
+
+	if owner == nil {
+		return hashBytes(s, password)
+	}
+
+	key := bind(owner, password)
+	defer scheme.Zero(key)
+
+	hash, err := hashBytes(s, key)
+	if err != nil {
+		return "", err
+	}
+
+	return boundPrefix + hash, nil
+}
+
+// hashBytes hashes the password using s, without converting
+// it to a string if s implements scheme.BytesScheme.
+func hashBytes(s scheme.Scheme, password []byte) (string, error) {
+	if b, ok := s.(scheme.BytesScheme); ok {
+		return b.HashBytes(password)
+	}
+
+	return s.Hash(string(password))
+}
+
+// verifyBytes verifies the password using s, without converting
+// it to a string if s implements scheme.BytesScheme.
+func verifyBytes(s scheme.Scheme, password []byte, hash string) error {
+	if b, ok := s.(scheme.BytesScheme); ok {
+		return b.VerifyBytes(password, hash)
+	}
+
+	return s.Verify(string(password), hash)
+}
+
+// normalize checks the password against the maximum length
+// and applies the configured normalization.
+// It returns a copy of the password, which the caller should zero.
+func (ctx *Context) normalize(password []byte) ([]byte, error) {
+	max := ctx.MaxPasswordLength
+	if max == 0 {
+		max = DefaultMaxPasswordLength
+	}
+
+	if max > 0 && len(password) > max {
+		return nil, ErrPasswordTooLong
+	}
+
+	normalized, err := ctx.Normalization.normalize(password)
+	if err != nil {
+		return nil, err
+	}
+
+	p := append([]byte(nil), normalized...)
+	if !sameBuffer(normalized, password) {
+		scheme.Zero(normalized)
+	}
+
+	// Compatibility decompositions may lengthen the password.
+	if max > 0 && len(p) > max {
+		scheme.Zero(p)
+		return nil, ErrPasswordTooLong
+	}
+
+	return p, nil
+}
+
+// truncates reports whether the preferred scheme truncates the password.
+func (ctx *Context) truncates(password []byte) bool {
+	t, ok := ctx.schemes()[0].(scheme.Truncater)
+	return ok && t.Truncates(password)
+}
+
+// Default returns the context used by the package-level functions.
+// It is DefaultContext unless another context was installed with SetDefault.
+// The returned context must not be modified; use Context.With
+// to derive a new one.
+func Default() *Context {
+	if ctx := defaultContext.Load(); ctx != nil {
+		return ctx
+	}
+
+	return &DefaultContext
+}
+
+// SetDefault atomically replaces the context used by the package-level functions.
+// It is safe to call while other goroutines hash and verify passwords.
+// ctx must not be modified afterwards.
+// If ctx is nil, DefaultContext is used again.
+func SetDefault(ctx *Context) {
+	defaultContext.Store(ctx)
 }
 
 // Hashes a UTF-8 plaintext password using the
@@ -107,7 +517,7 @@ func (ctx *Context) verify(password, hash string, canUpgrade bool) (newHash stri
 // based on the configured policy.
 // The default policy is sensible.
 func Hash(password string) (hash string, err error) {
-	return DefaultContext.Hash(password)
+	return Default().Hash(password)
 }
 
 // Verifies a UTF-8 plaintext password using a previously derived password hash and the default context.
@@ -118,15 +528,27 @@ func Hash(password string) (hash string, err error) {
 // newHash is empty if the password was invalid or no upgrade is required.
 // You should treat any non-nil err as a password verification error.
 func Verify(password, hash string) (newHash string, err error) {
-	return DefaultContext.Verify(password, hash)
+	return Default().Verify(password, hash)
+}
+
+// Like Hash, but takes the password as a byte slice.
+// See Context.HashBytes.
+func HashBytes(password []byte) (hash string, err error) {
+	return Default().HashBytes(password)
+}
+
+// Like Verify, but takes the password as a byte slice.
+// See Context.VerifyBytes.
+func VerifyBytes(password []byte, hash string) (newHash string, err error) {
+	return Default().VerifyBytes(password, hash)
 }
 
 // Verify, but never upgrades.
 func VerifyNoUpgrade(password, hash string) error {
-	return DefaultContext.VerifyNoUpgrade(password, hash)
+	return Default().VerifyNoUpgrade(password, hash)
 }
 
 // Uses the default context to determine whether a stub or hash needs updating.
 func NeedsUpdate(stub string) bool {
-	return DefaultContext.NeedsUpdate(stub)
+	return Default().NeedsUpdate(stub)
 }
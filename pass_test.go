@@ -1,11 +1,18 @@
 package pass
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pchchv/pass/hash/argon2"
 	"github.com/pchchv/pass/hash/bcrypt"
 	"github.com/pchchv/pass/hash/bcryptsha256"
+	"github.com/pchchv/pass/hash/disabled"
+	"github.com/pchchv/pass/hash/plaintext"
 	"github.com/pchchv/pass/hash/scrypt"
 	"github.com/pchchv/pass/hash/sha2"
 	"github.com/pchchv/pass/scheme"
@@ -54,13 +61,13 @@ func TestDefault(t *testing.T) {
 		t.Fatalf("unexpected upgrade")
 	}
 	newHash, err = Verify("foobar", "$s2$16384$8$1$qa9lVfhmTE8F2Jpwya9m7uoE$Q7dSPqhZQCLWpjniaz7RVm+xorpSAPTvOCP2uoZmoiI=")
-	//$argon2i$v=19$m=32768,t=4,p=4$c29tZXNhbHRzb21lYWxrdA$HcTlbOnOAzJ2dUrlgHnNwC0yallJ/Gl2NbAWqg4IukA")
 	if err != nil {
 		t.Fatalf("err verifying known good: %v", err)
 	}
 
-	if newHash != "" {
-		t.Fatalf("unexpected upgrade")
+	// scrypt is not the preferred default scheme.
+	if !strings.HasPrefix(newHash, "$argon2i$") {
+		t.Fatalf("expected upgrade to argon2, got %q", newHash)
 	}
 
 	// Now test new defaults.
@@ -245,3 +252,564 @@ func TestKat(t *testing.T) {
 		kat(t, argon2.Crypter, v.p, v.h)
 	}
 }
+
+func TestTruncationUpgrade(t *testing.T) {
+	long := strings.Repeat("a", 80)
+	legacy, err := bcrypt.New(4).Hash(long)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c := Context{Schemes: []scheme.Scheme{bcrypt.New(4)}}
+	if newHash, err := c.Verify(long, legacy); err != nil || newHash != "" {
+		t.Fatalf("unexpected upgrade with truncating preferred scheme: %q, %v", newHash, err)
+	}
+
+	c = Context{Schemes: []scheme.Scheme{bcrypt.NewWithPolicy(4, bcrypt.Prehash)}}
+	newHash, err := c.Verify(long, legacy)
+	if err != nil {
+		t.Fatalf("err verifying: %v", err)
+	}
+	if newHash == "" {
+		t.Fatalf("empty newHash when verifying truncated hash")
+	}
+
+	if newHash2, err := c.Verify(long, newHash); err != nil || newHash2 != "" {
+		t.Fatalf("unexpected result after upgrade: %q, %v", newHash2, err)
+	}
+}
+
+func TestVerifyDetailed(t *testing.T) {
+	old := sha2.NewCrypter256WithSaltLength(1000, 8)
+	h, err := old.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c := Context{Schemes: []scheme.Scheme{bcrypt.New(4), sha2.NewCrypter256(5000)}}
+	res, err := c.VerifyDetailed("password", h)
+	if err != nil {
+		t.Fatalf("err verifying: %v", err)
+	}
+
+	if res.Scheme != c.Schemes[1] {
+		t.Errorf("unexpected scheme %v", res.Scheme)
+	}
+
+	if res.Params["rounds"] != "1000" || res.Params["salt_length"] != "8" {
+		t.Errorf("unexpected params %v", res.Params)
+	}
+
+	want := []scheme.UpdateReason{scheme.ReasonNotPreferred, scheme.ReasonCost, scheme.ReasonSalt}
+	if !reflect.DeepEqual(res.Reasons, want) {
+		t.Errorf("got reasons %v, want %v", res.Reasons, want)
+	}
+
+	if !strings.HasPrefix(res.NewHash, "$2b$04$") {
+		t.Errorf("unexpected newHash %q", res.NewHash)
+	}
+
+	res, err = c.VerifyDetailed("password", res.NewHash)
+	if err != nil {
+		t.Fatalf("err verifying after upgrade: %v", err)
+	}
+
+	if len(res.Reasons) != 0 || res.NewHash != "" {
+		t.Errorf("unexpected upgrade after upgrade: %v %q", res.Reasons, res.NewHash)
+	}
+
+	if _, err := c.VerifyDetailed("wrong", h); err == nil {
+		t.Errorf("invalid password accepted")
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	c := Context{Schemes: []scheme.Scheme{scrypt.SHA256Crypter, bcrypt.NewWithPolicy(4, bcrypt.TruncateError)}}
+
+	_, err := c.Verify("password", "$s2$16384$8$x$c2FsdA==$aGFzaA==")
+	if !errors.Is(err, scheme.ErrMalformedHash) {
+		t.Errorf("expected malformed hash, got %v", err)
+	}
+
+	var e *scheme.Error
+	if !errors.As(err, &e) || e.Scheme != "scrypt" || e.Field != "p" {
+		t.Errorf("unexpected error details %#v", e)
+	}
+
+	_, err = c.Verify("password", "$2b$99$abcdefghijklmnopqrstuu")
+	if !errors.Is(err, scheme.ErrUnsupportedParameter) {
+		t.Errorf("expected unsupported parameter, got %v", err)
+	}
+
+	h, err := c.Schemes[1].Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	_, err = c.Verify("wrong", h)
+	if !errors.Is(err, scheme.ErrPasswordMismatch) || errors.Is(err, scheme.ErrMalformedHash) {
+		t.Errorf("expected password mismatch, got %v", err)
+	}
+
+	_, err = c.Schemes[1].Hash(strings.Repeat("a", 100))
+	if !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected policy rejection, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, hash := range []string{
+		"$pbkdf2-sha256$2147483647$c2FsdA$aGFzaA",
+		"$6$rounds=999999999$salt$hash",
+		"$s2$1073741824$8$1$c2FsdA==$aGFzaA==",
+		"$s2$16384$8$1000$c2FsdA==$aGFzaA==",
+		"$argon2i$v=19$m=4294967295,t=4,p=4$c2FsdHNhbHQ$aGFzaA",
+		"$argon2i$v=19$m=32768,t=4294967295,p=4$c2FsdHNhbHQ$aGFzaA",
+		"$2b$31$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		"$bcrypt-sha256$v=2,t=2b,r=31$n79VH.0Q2TMWmt3Oqt9uku$Kq4Noyk3094Y2QlB8NdRT8SvGiI4ft2",
+	} {
+		if _, err := Verify("password", hash); !errors.Is(err, scheme.ErrPolicyRejected) {
+			t.Errorf("%s: expected policy rejection, got %v", hash, err)
+		}
+	}
+
+	h, err := bcrypt.New(5).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c := Context{Limits: &scheme.Limits{MaxLogRounds: 4}}
+	if _, err := c.Verify("password", h); !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected policy rejection, got %v", err)
+	}
+
+	c.Limits.MaxLogRounds = 5
+	if _, err := c.Verify("password", h); err != nil {
+		t.Errorf("err verifying: %v", err)
+	}
+}
+
+func TestSchemeLimits(t *testing.T) {
+	h, err := sha2.NewCrypter256(2000).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	s := sha2.NewCrypter256WithOptions(sha2.WithRounds(1000), sha2.WithLimits(scheme.Limits{MaxRounds: 1500}))
+	if err := s.Verify("password", h); !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected policy rejection, got %v", err)
+	}
+
+	// The scheme raises its ceilings to verify its own hashes.
+	s = sha2.NewCrypter256WithOptions(sha2.WithRounds(2000), sha2.WithLimits(scheme.Limits{MaxRounds: 1500}))
+	if err := s.Verify("password", h); err != nil {
+		t.Errorf("err verifying: %v", err)
+	}
+
+	h, err = bcrypt.New(5).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	s, err = scheme.WithLimits(bcrypt.New(4), scheme.Limits{MaxLogRounds: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c := Context{Schemes: []scheme.Scheme{s}}
+	if _, err := c.Verify("password", h); !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected policy rejection, got %v", err)
+	}
+
+	if _, err := scheme.WithLimits(plaintext.Crypter, scheme.Limits{}); err != scheme.ErrLimitsUnsupported {
+		t.Errorf("expected ErrLimitsUnsupported, got %v", err)
+	}
+}
+
+func TestMaxPasswordLength(t *testing.T) {
+	c := Context{Schemes: []scheme.Scheme{sha2.NewCrypter256(1000)}}
+	long := strings.Repeat("a", DefaultMaxPasswordLength+1)
+
+	if _, err := c.Hash(long); err != ErrPasswordTooLong {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+
+	h, err := c.Hash(long[1:])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := c.Verify(long, h); !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected policy rejection, got %v", err)
+	}
+
+	c.MaxPasswordLength = 8
+	if err := c.VerifyNoUpgrade("password1", h); err != ErrPasswordTooLong {
+		t.Errorf("expected ErrPasswordTooLong, got %v", err)
+	}
+
+	c.MaxPasswordLength = -1
+	if _, err := c.Hash(long); err != nil {
+		t.Errorf("unexpected error without limit: %v", err)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for _, s := range DefaultSchemes {
+		if _, ok := s.(scheme.BytesScheme); !ok {
+			t.Errorf("%v does not implement scheme.BytesScheme", s)
+		}
+	}
+
+	for _, s := range []scheme.Scheme{
+		argon2.New(1, 1024, 1),
+		scrypt.NewSHA256(16, 1, 1),
+		sha2.NewCrypter512(1000),
+		bcrypt.NewWithPolicy(4, bcrypt.Prehash),
+		bcryptsha256.New(4),
+	} {
+		c := Context{Schemes: []scheme.Scheme{s}, Normalization: NormalizeNFKC}
+		password := []byte(strings.Repeat("paßword", 10))
+		original := string(password)
+
+		h, err := c.HashBytes(password)
+		if err != nil {
+			t.Fatalf("%v: err: %v", s, err)
+		}
+
+		if newHash, err := c.VerifyBytes(password, h); err != nil || newHash != "" {
+			t.Errorf("%v: unexpected result: %q, %v", s, newHash, err)
+		}
+
+		if string(password) != original {
+			t.Errorf("%v: password was modified", s)
+		}
+
+		if _, err := c.Verify(original, h); err != nil {
+			t.Errorf("%v: string verification failed: %v", s, err)
+		}
+
+		if _, err := c.VerifyBytes([]byte("wrong"), h); err != scheme.ErrPasswordMismatch {
+			t.Errorf("%v: expected mismatch, got %v", s, err)
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	c := &Context{Schemes: []scheme.Scheme{sha2.NewCrypter256(1000)}, Limits: &scheme.Limits{MaxRounds: 2000}}
+	d := c.With(WithNormalization(NormalizeNFC), WithLimits(scheme.Limits{MaxRounds: 500}))
+
+	if c.Normalization != NormalizeNone || c.Limits.MaxRounds != 2000 {
+		t.Errorf("With modified the original context")
+	}
+
+	if d.Normalization != NormalizeNFC || d.Limits.MaxRounds != 500 || d.Schemes[0] != c.Schemes[0] {
+		t.Errorf("unexpected derived context %+v", d)
+	}
+
+	d.Schemes[0] = sha2.Crypter512
+	if c.Schemes[0] == sha2.Crypter512 {
+		t.Errorf("derived context shares the schemes slice")
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	defer SetDefault(nil)
+
+	h, err := sha2.NewCrypter256(1000).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := VerifyNoUpgrade("password", h); err != nil {
+					t.Errorf("err: %v", err)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		SetDefault(Default().With(WithSchemes(sha2.NewCrypter256(1000+i), sha2.Crypter512)))
+	}
+
+	wg.Wait()
+
+	if fmt.Sprint(Default().Schemes[0]) != "sha256-crypt(1019,16)" {
+		t.Errorf("unexpected default context %v", Default().Schemes)
+	}
+
+	SetDefault(nil)
+	if Default() != &DefaultContext {
+		t.Errorf("SetDefault(nil) did not restore DefaultContext")
+	}
+}
+
+func TestCategories(t *testing.T) {
+	user := sha2.NewCrypter256(1000)
+	admin := &Context{Schemes: []scheme.Scheme{sha2.NewCrypter512(2000)}}
+	c := (&Context{Schemes: []scheme.Scheme{user, sha2.Crypter512}}).With(WithCategory("admin", admin))
+
+	h, err := c.HashFor("user", "password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !strings.HasPrefix(h, "$5$rounds=1000$") || c.NeedsUpdateFor("user", h) {
+		t.Errorf("unexpected hash for an unknown category: %s", h)
+	}
+
+	// The account is promoted: the hash is verified with the schemes
+	// of the parent context and upgraded to the admin policy.
+	if !c.NeedsUpdateFor("admin", h) {
+		t.Errorf("hash should need updating for the admin category")
+	}
+
+	newHash, err := c.VerifyFor("admin", "password", h)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !strings.HasPrefix(newHash, "$6$rounds=2000$") || c.NeedsUpdateFor("admin", newHash) {
+		t.Errorf("unexpected upgraded hash %s", newHash)
+	}
+
+	if _, err := c.VerifyFor("admin", "wrong", h); !errors.Is(err, scheme.ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch, got %v", err)
+	}
+
+	// The weaker sha512-crypt hash of a regular user is upgraded by cost.
+	h, err = sha2.NewCrypter512(1000).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if c.NeedsUpdateFor("user", h) != c.NeedsUpdate(h) || !c.NeedsUpdateFor("admin", h) {
+		t.Errorf("unexpected NeedsUpdateFor results for %s", h)
+	}
+
+	if len(admin.Schemes) != 1 {
+		t.Errorf("category context was modified: %v", admin.Schemes)
+	}
+}
+
+func TestCategoriesConcurrent(t *testing.T) {
+	// The category has three schemes, so its slice has spare capacity.
+	c, err := ParsePolicy([]byte(`{
+		"schemes": [{"name": "bcrypt", "params": {"cost": 4}}],
+		"categories": {"admin": {"schemes": [
+			{"name": "sha512-crypt", "params": {"rounds": 1000}},
+			{"name": "sha256-crypt", "params": {"rounds": 1000}},
+			{"name": "phpass"}
+		]}}
+	}`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	admin := c.Categories["admin"]
+	if cap(admin.Schemes) == len(admin.Schemes) {
+		t.Fatalf("expected spare capacity in %v", admin.Schemes)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				h, err := c.HashFor("admin", "password")
+				if err == nil {
+					_, err = c.VerifyFor("admin", "password", h)
+				}
+
+				if err != nil || c.NeedsUpdateFor("admin", h) {
+					t.Errorf("unexpected result %q, %v", h, err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(admin.Schemes) != 3 {
+		t.Errorf("category context was modified: %v", admin.Schemes)
+	}
+}
+
+func TestCategoryInheritance(t *testing.T) {
+	c := &Context{
+		Schemes:       []scheme.Scheme{sha2.NewCrypter256(1000)},
+		Limits:        &scheme.Limits{MaxRounds: 1500},
+		Normalization: NormalizeNFKC,
+		Binding:       BindRequire,
+		Categories: map[string]*Context{
+			"admin": {Schemes: []scheme.Scheme{sha2.NewCrypter512(1000)}},
+		},
+	}
+
+	// The category inherits the binding of the parent.
+	if _, err := c.HashFor("admin", "password"); err != ErrOwnerRequired {
+		t.Errorf("expected ErrOwnerRequired, got %v", err)
+	}
+
+	admin := c.Category("admin")
+	if admin.Normalization != NormalizeNFKC || admin.Binding != BindRequire || admin.Limits == c.Limits {
+		t.Errorf("unexpected category context %+v", admin)
+	}
+
+	h, err := admin.HashBound("alice", "password")
+	if err != nil || !strings.HasPrefix(h, "{BOUND}$6$rounds=1000$") {
+		t.Fatalf("HashBound: %q, %v", h, err)
+	}
+
+	// NFKC maps the fullwidth letters to ASCII.
+	if _, err := admin.VerifyBound("alice", "ｐａｓｓｗｏｒｄ", h); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	unbound, err := sha2.NewCrypter512(1000).Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := admin.VerifyBound("alice", "password", unbound); err != ErrUnbound {
+		t.Errorf("expected ErrUnbound, got %v", err)
+	}
+
+	// The category inherits the limits of the parent.
+	expensive, err := (&Context{Schemes: []scheme.Scheme{sha2.NewCrypter512(2000)}}).HashBound("alice", "password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := admin.VerifyBound("alice", "password", expensive); !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected ErrPolicyRejected, got %v", err)
+	}
+
+	// Fields set by the category override the parent.
+	c.Categories["admin"].Limits = &scheme.Limits{MaxRounds: 5000}
+	if _, err := c.Category("admin").VerifyBound("alice", "password", expensive); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	if c.Category("user") != c {
+		t.Errorf("unknown categories should use the context itself")
+	}
+}
+
+func TestBinding(t *testing.T) {
+	c := &Context{Schemes: []scheme.Scheme{sha2.NewCrypter256(1000), bcrypt.New(4)}}
+
+	unbound, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	h, err := c.HashBound("alice", "password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !strings.HasPrefix(h, "{BOUND}$5$rounds=1000$") || c.NeedsUpdate(h) {
+		t.Errorf("unexpected bound hash %s", h)
+	}
+
+	if newHash, err := c.VerifyBound("alice", "password", h); err != nil || newHash != "" {
+		t.Errorf("VerifyBound: %q, %v", newHash, err)
+	}
+
+	// The hash does not verify for another owner or without one.
+	if _, err := c.VerifyBound("mallory", "password", h); !errors.Is(err, scheme.ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch, got %v", err)
+	}
+
+	if _, err := c.Verify("password", h); err != ErrOwnerRequired {
+		t.Errorf("expected ErrOwnerRequired, got %v", err)
+	}
+
+	if _, err := c.HashBound("", "password"); err != ErrOwnerRequired {
+		t.Errorf("expected ErrOwnerRequired, got %v", err)
+	}
+
+	// Bound hashes of other schemes are upgraded and stay bound.
+	b := c.With(WithSchemes(bcrypt.New(4)))
+	old, err := b.HashBound("alice", "password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !c.NeedsUpdate(old) {
+		t.Errorf("bound bcrypt hash should need updating")
+	}
+
+	newHash, err := c.VerifyBound("alice", "password", old)
+	if err != nil || !strings.HasPrefix(newHash, "{BOUND}$5$") {
+		t.Errorf("VerifyBound: %q, %v", newHash, err)
+	}
+
+	// Migration: unbound hashes are accepted and upgraded.
+	m := c.With(WithBinding(BindUpgrade))
+	if !m.NeedsUpdate(unbound) || m.NeedsUpdate(h) {
+		t.Errorf("unexpected NeedsUpdate results with BindUpgrade")
+	}
+
+	if _, err := m.Hash("password"); err != ErrOwnerRequired {
+		t.Errorf("expected ErrOwnerRequired, got %v", err)
+	}
+
+	res, err := m.verify([]byte("password"), unbound, []byte("alice"), true)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(res.Reasons, []scheme.UpdateReason{scheme.ReasonUnbound}) || !strings.HasPrefix(res.NewHash, "{BOUND}") {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	if _, err := m.VerifyBound("alice", "password", res.NewHash); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	// Once migrated, unbound hashes may have been copied and are rejected.
+	r := c.With(WithBinding(BindRequire))
+	if _, err := r.VerifyBound("alice", "password", unbound); err != ErrUnbound || !errors.Is(err, scheme.ErrPolicyRejected) {
+		t.Errorf("expected ErrUnbound, got %v", err)
+	}
+
+	if _, err := r.VerifyBound("alice", "password", h); err != nil {
+		t.Errorf("err: %v", err)
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	c := &Context{Schemes: []scheme.Scheme{sha2.NewCrypter256(1000), plaintext.Crypter, disabled.Crypter}}
+
+	newHash, err := c.Verify("password", "{PLAIN}password")
+	if err != nil || !strings.HasPrefix(newHash, "$5$rounds=1000$") {
+		t.Errorf("plaintext password not upgraded: %q, %v", newHash, err)
+	}
+
+	if !c.NeedsUpdate("{PLAIN}password") || c.NeedsUpdate(newHash) {
+		t.Errorf("unexpected NeedsUpdate results for %s", newHash)
+	}
+
+	if _, err := c.Verify("wrong", "{PLAIN}password"); !errors.Is(err, scheme.ErrPasswordMismatch) {
+		t.Errorf("expected ErrPasswordMismatch, got %v", err)
+	}
+
+	for _, h := range []string{disabled.Disable(newHash), "!", "!!", "*"} {
+		if newHash, err := c.Verify("password", h); err != disabled.ErrDisabled || newHash != "" {
+			t.Errorf("%s: expected ErrDisabled, got %q, %v", h, newHash, err)
+		}
+
+		if c.NeedsUpdate(h) {
+			t.Errorf("disabled hash %s should not need updating", h)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package pass
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pchchv/pass/hash/argon2"
+	argon2raw "github.com/pchchv/pass/hash/argon2/raw"
+	"github.com/pchchv/pass/hash/atlassian"
+	"github.com/pchchv/pass/hash/bcrypt"
+	bcryptraw "github.com/pchchv/pass/hash/bcrypt/raw"
+	"github.com/pchchv/pass/hash/bcryptsha256"
+	"github.com/pchchv/pass/hash/cisco"
+	"github.com/pchchv/pass/hash/grub"
+	"github.com/pchchv/pass/hash/pbkdf2"
+	pbkdf2raw "github.com/pchchv/pass/hash/pbkdf2/raw"
+	"github.com/pchchv/pass/hash/phpass"
+	"github.com/pchchv/pass/hash/scrypt"
+	scryptraw "github.com/pchchv/pass/hash/scrypt/raw"
+	"github.com/pchchv/pass/hash/sha2"
+	sha2raw "github.com/pchchv/pass/hash/sha2/raw"
+	"github.com/pchchv/pass/scheme"
+)
+
+// Returned, wrapped, when a policy cannot be parsed or is invalid.
+var ErrInvalidPolicy = errors.New("invalid password policy")
+
+// Policy is the serializable configuration of a Context,
+// as read from a policy file by a Watcher. For example:
+//
+//	{
+//	    "schemes": [
+//	        {"name": "argon2", "params": {"time": 4, "memory": 65536, "threads": 4}},
+//	        {"name": "bcrypt", "params": {"cost": 12}},
+//	        {"name": "sha512-crypt"}
+//	    ],
+//	    "limits": {"max_rounds": 1000000},
+//	    "normalization": "nfc",
+//	    "categories": {
+//	        "admin": {"schemes": [{"name": "argon2", "params": {"time": 8, "memory": 65536, "threads": 4}}]}
+//	    }
+//	}
+//
+// Scheme parameters which are not given use the recommended values.
+type Policy struct {
+	// The schemes, the most preferred ones first.
+	// The names are those accepted by ParsePolicy.
+	Schemes []SchemePolicy `json:"schemes"`
+	// See Context.Limits.
+	Limits *scheme.Limits `json:"limits,omitempty"`
+	// See Context.MaxPasswordLength.
+	MaxPasswordLength int `json:"max_password_length,omitempty"`
+	// One of "none", "nfc", "nfkc" or "saslprep".
+	Normalization string `json:"normalization,omitempty"`
+	// See Context.VerifyUnnormalized.
+	VerifyUnnormalized bool `json:"verify_unnormalized,omitempty"`
+	// One of "none", "upgrade" or "require", see Binding.
+	Binding string `json:"binding,omitempty"`
+	// The policies of categories of users, see Context.Categories.
+	// Categories cannot be nested.
+	Categories map[string]Policy `json:"categories,omitempty"`
+}
+
+// SchemePolicy configures one scheme of a Policy.
+type SchemePolicy struct {
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// ParsePolicy parses and validates a JSON policy and returns the context it describes.
+// The supported scheme names and their parameters are:
+//
+//	argon2          time, memory, threads
+//	scrypt-sha256   N, r, p
+//	sha256-crypt    rounds, salt_length
+//	sha512-crypt    rounds, salt_length
+//	bcrypt          cost, truncation ("truncate", "error" or "prehash")
+//	bcrypt-sha256   cost, version
+//	pbkdf2-sha1     rounds
+//	pbkdf2-sha256   rounds
+//	pbkdf2-sha512   rounds
+//	grub            rounds
+//	phpass          (verify only)
+//	atlassian       (verify only)
+//	cisco-type8     (verify only)
+//	cisco-type9     (verify only)
+//
+// Unknown fields, schemes and parameters are rejected.
+func ParsePolicy(data []byte) (*Context, error) {
+	var p Policy
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+
+	return p.Context()
+}
+
+// Context validates the policy and returns the context it describes.
+// The context is checked by hashing and verifying a test password,
+// so that a policy whose limits reject the hashes of its preferred
+// scheme is reported as invalid.
+func (p *Policy) Context() (*Context, error) {
+	if len(p.Schemes) == 0 {
+		return nil, fmt.Errorf("%w: no schemes", ErrInvalidPolicy)
+	}
+
+	ctx := &Context{
+		MaxPasswordLength:  p.MaxPasswordLength,
+		VerifyUnnormalized: p.VerifyUnnormalized,
+	}
+
+	for _, sp := range p.Schemes {
+		s, err := sp.scheme()
+		if err != nil {
+			return nil, fmt.Errorf("%w: scheme %q: %v", ErrInvalidPolicy, sp.Name, err)
+		}
+
+		ctx.Schemes = append(ctx.Schemes, s)
+	}
+
+	if p.Limits != nil {
+		l := *p.Limits
+		if l.MaxRounds < 0 || l.MaxLogRounds < 0 || l.MaxPasses < 0 {
+			return nil, fmt.Errorf("%w: negative limit", ErrInvalidPolicy)
+		}
+
+		ctx.Limits = &l
+	}
+
+	switch p.Normalization {
+	case "", "none":
+		ctx.Normalization = NormalizeNone
+	case "nfc":
+		ctx.Normalization = NormalizeNFC
+	case "nfkc":
+		ctx.Normalization = NormalizeNFKC
+	case "saslprep":
+		ctx.Normalization = NormalizeSASLprep
+	default:
+		return nil, fmt.Errorf("%w: unknown normalization %q", ErrInvalidPolicy, p.Normalization)
+	}
+
+	switch p.Binding {
+	case "", "none":
+		ctx.Binding = BindNone
+	case "upgrade":
+		ctx.Binding = BindUpgrade
+	case "require":
+		ctx.Binding = BindRequire
+	default:
+		return nil, fmt.Errorf("%w: unknown binding %q", ErrInvalidPolicy, p.Binding)
+	}
+
+	for name, cp := range p.Categories {
+		if len(cp.Categories) != 0 {
+			return nil, fmt.Errorf("%w: category %q: nested categories", ErrInvalidPolicy, name)
+		}
+
+		c, err := cp.Context()
+		if err != nil {
+			return nil, fmt.Errorf("category %q: %w", name, err)
+		}
+
+		if ctx.Categories == nil {
+			ctx.Categories = map[string]*Context{}
+		}
+
+		ctx.Categories[name] = c
+	}
+
+	var hash string
+	var err error
+	if ctx.Binding == BindNone {
+		hash, err = ctx.Hash("password")
+		if err == nil {
+			err = ctx.VerifyNoUpgrade("password", hash)
+		}
+	} else {
+		hash, err = ctx.HashBound("owner", "password")
+		if err == nil {
+			_, err = ctx.VerifyBound("owner", "password", hash)
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+
+	return ctx, nil
+}
+
+func (sp SchemePolicy) scheme() (s scheme.Scheme, err error) {
+	p := &params{m: sp.Params}
+	switch sp.Name {
+	case "argon2":
+		time := p.int("time", int(argon2raw.RecommendedTime), 1, math.MaxInt32)
+		memory := p.int("memory", int(argon2raw.RecommendedMemory), 8, math.MaxInt32)
+		threads := p.int("threads", int(argon2raw.RecommendedThreads), 1, math.MaxUint8)
+		s = argon2.New(uint32(time), uint32(memory), uint8(threads))
+	case "scrypt-sha256":
+		N := p.int("N", scryptraw.RecommendedN, 2, math.MaxInt32)
+		if N&(N-1) != 0 {
+			p.fail("N", N)
+		}
+
+		r := p.int("r", scryptraw.Recommendedr, 1, math.MaxInt32)
+		s = scrypt.NewSHA256(N, r, p.int("p", scryptraw.Recommendedp, 1, math.MaxInt32))
+	case "sha256-crypt", "sha512-crypt":
+		opts := []sha2.Option{
+			sha2.WithRounds(p.int("rounds", sha2raw.RecommendedRounds, sha2raw.MinimumRounds, sha2raw.MaximumRounds)),
+			sha2.WithSaltLength(p.int("salt_length", sha2.RecommendedSaltLength, 1, sha2raw.MaxSaltLength)),
+		}
+
+		if sp.Name == "sha256-crypt" {
+			s = sha2.NewCrypter256WithOptions(opts...)
+		} else {
+			s = sha2.NewCrypter512WithOptions(opts...)
+		}
+	case "bcrypt":
+		cost := p.int("cost", bcrypt.RecommendedCost, bcryptraw.MinCost, bcryptraw.MaxCost)
+		policy := bcrypt.Truncate
+		switch t := p.string("truncation", "truncate"); t {
+		case "truncate":
+		case "error":
+			policy = bcrypt.TruncateError
+		case "prehash":
+			policy = bcrypt.Prehash
+		default:
+			p.fail("truncation", t)
+		}
+
+		s = bcrypt.NewWithPolicy(cost, policy)
+	case "bcrypt-sha256":
+		cost := p.int("cost", bcryptsha256.RecommendedCost, bcryptraw.MinCost, bcryptraw.MaxCost)
+		if p.int("version", bcryptsha256.RecommendedVersion, 1, 2) == 1 {
+			s = bcryptsha256.NewV1(cost)
+		} else {
+			s = bcryptsha256.New(cost)
+		}
+	case "pbkdf2-sha1":
+		s = pbkdf2.New("$pbkdf2$", sha1.New, p.int("rounds", pbkdf2.RecommendedRoundsSHA1, pbkdf2raw.MinRounds, pbkdf2raw.MaxRounds))
+	case "pbkdf2-sha256":
+		s = pbkdf2.New("$pbkdf2-sha256$", sha256.New, p.int("rounds", pbkdf2.RecommendedRoundsSHA256, pbkdf2raw.MinRounds, pbkdf2raw.MaxRounds))
+	case "pbkdf2-sha512":
+		s = pbkdf2.New("$pbkdf2-sha512$", sha512.New, p.int("rounds", pbkdf2.RecommendedRoundsSHA512, pbkdf2raw.MinRounds, pbkdf2raw.MaxRounds))
+	case "grub":
+		s = grub.New(p.int("rounds", grub.RecommendedRounds, pbkdf2raw.MinRounds, pbkdf2raw.MaxRounds))
+	case "phpass":
+		s = phpass.Crypter
+	case "atlassian":
+		s = atlassian.Crypter
+	case "cisco-type8":
+		s = cisco.Type8Crypter
+	case "cisco-type9":
+		s = cisco.Type9Crypter
+	default:
+		return nil, errors.New("unknown scheme")
+	}
+
+	if err = p.check(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// params reads scheme parameters, recording the first invalid one.
+type params struct {
+	m    map[string]any
+	used []string
+	err  error
+}
+
+func (p *params) int(key string, def, min, max int) int {
+	v, ok := p.get(key)
+	if !ok {
+		return def
+	}
+
+	f, ok := v.(float64)
+	if !ok || f != math.Trunc(f) || f < float64(min) || f > float64(max) {
+		p.fail(key, v)
+		return def
+	}
+
+	return int(f)
+}
+
+func (p *params) string(key, def string) string {
+	v, ok := p.get(key)
+	if !ok {
+		return def
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		p.fail(key, v)
+		return def
+	}
+
+	return s
+}
+
+func (p *params) get(key string) (any, bool) {
+	p.used = append(p.used, key)
+	v, ok := p.m[key]
+
+	return v, ok
+}
+
+func (p *params) fail(key string, v any) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid value %v for parameter %q", v, key)
+	}
+}
+
+// check returns the first invalid parameter or any unknown parameter.
+func (p *params) check() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	var unknown []string
+	for key := range p.m {
+		found := false
+		for _, u := range p.used {
+			found = found || u == key
+		}
+
+		if !found {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) != 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown parameter %q", unknown[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package pass
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	ctx, err := ParsePolicy([]byte(`{
+		"schemes": [
+			{"name": "sha512-crypt", "params": {"rounds": 1000, "salt_length": 8}},
+			{"name": "bcrypt", "params": {"cost": 4, "truncation": "prehash"}},
+			{"name": "phpass"}
+		],
+		"limits": {"max_rounds": 5000},
+		"normalization": "nfc",
+		"categories": {"admin": {"schemes": [{"name": "sha512-crypt", "params": {"rounds": 2000}}]}}
+	}`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if len(ctx.Schemes) != 3 || fmt.Sprint(ctx.Schemes[0]) != "sha512-crypt(1000,8)" || fmt.Sprint(ctx.Schemes[1]) != "bcrypt(4)" {
+		t.Errorf("unexpected schemes %v", ctx.Schemes)
+	}
+
+	if ctx.Limits == nil || ctx.Limits.MaxRounds != 5000 || ctx.Normalization != NormalizeNFC {
+		t.Errorf("unexpected context %+v", ctx)
+	}
+
+	if admin := ctx.Categories["admin"]; admin == nil || fmt.Sprint(admin.Schemes[0]) != "sha512-crypt(2000,16)" {
+		t.Errorf("unexpected categories %v", ctx.Categories)
+	}
+
+	for _, policy := range []string{
+		`{}`,
+		`{"schemes": [{"name": "md4"}]}`,
+		`{"schemes": [{"name": "bcrypt", "params": {"cost": 3}}]}`,
+		`{"schemes": [{"name": "bcrypt", "params": {"cost": "4"}}]}`,
+		`{"schemes": [{"name": "bcrypt", "params": {"rounds": 4}}]}`,
+		`{"schemes": [{"name": "bcrypt", "params": {"cost": 4, "truncation": "ignore"}}]}`,
+		`{"schemes": [{"name": "scrypt-sha256", "params": {"N": 1000}}]}`,
+		`{"schemes": [{"name": "sha256-crypt"}], "normalization": "nfd"}`,
+		`{"schemes": [{"name": "sha256-crypt"}], "color": "red"}`,
+		`{"schemes": [{"name": "sha256-crypt"}], "binding": "always"}`,
+		// Verify only schemes cannot be preferred.
+		`{"schemes": [{"name": "phpass"}]}`,
+		// The limits reject hashes of the preferred scheme.
+		`{"schemes": [{"name": "sha256-crypt", "params": {"rounds": 2000}}], "limits": {"max_rounds": 1000}}`,
+		`{"schemes": [{"name": "sha256-crypt"}], "categories": {"admin": {}}}`,
+		`{"schemes": [{"name": "sha256-crypt"}], "categories": {"admin": {"schemes": [{"name": "sha256-crypt"}], "categories": {"root": {}}}}}`,
+		`{"schemes": [`,
+	} {
+		if _, err := ParsePolicy([]byte(policy)); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: expected ErrInvalidPolicy, got %v", policy, err)
+		}
+	}
+}
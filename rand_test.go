@@ -0,0 +1,81 @@
+package pass
+
+import (
+	"crypto/sha1"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pchchv/pass/hash/argon2"
+	"github.com/pchchv/pass/hash/atlassian"
+	"github.com/pchchv/pass/hash/bcrypt"
+	"github.com/pchchv/pass/hash/bcryptsha256"
+	"github.com/pchchv/pass/hash/cisco"
+	"github.com/pchchv/pass/hash/grub"
+	"github.com/pchchv/pass/hash/ldap"
+	"github.com/pchchv/pass/hash/pbkdf2"
+	"github.com/pchchv/pass/hash/phpass"
+	"github.com/pchchv/pass/hash/scrypt"
+	"github.com/pchchv/pass/hash/sha2"
+	"github.com/pchchv/pass/scheme"
+)
+
+// constReader is a deterministic source of randomness for tests.
+type constReader byte
+
+func (r constReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = byte(r)
+	}
+
+	return len(b), nil
+}
+
+func TestRand(t *testing.T) {
+	for _, s := range []scheme.Scheme{
+		argon2.NewWithOptions(argon2.WithTime(1), argon2.WithMemory(1024), argon2.WithThreads(1)),
+		scrypt.NewSHA256(16, 1, 1),
+		sha2.NewCrypter512(1000),
+		bcrypt.New(4),
+		bcryptsha256.New(4),
+		pbkdf2.New("$pbkdf2$", sha1.New, 1000),
+		grub.New(1000),
+		phpass.New(7),
+		atlassian.New(),
+		cisco.NewType8(),
+		ldap.SaltedSHA1Crypter,
+		ldap.NewCrypt(sha2.NewCrypter256(1000)),
+	} {
+		c := Context{Schemes: []scheme.Scheme{s}, Rand: constReader(42)}
+		h1, err := c.Hash("password")
+		if err != nil {
+			t.Fatalf("%v: err: %v", s, err)
+		}
+
+		h2, err := c.Hash("password")
+		if err != nil || h1 != h2 {
+			t.Errorf("%v: hashes differ with a deterministic source: %q, %q, %v", s, h1, h2, err)
+		}
+
+		if err := c.VerifyNoUpgrade("password", h1); err != nil {
+			t.Errorf("%v: err: %v", s, err)
+		}
+
+		c.Rand = iotest.ErrReader(errors.New("rng failure"))
+		if h, err := c.Hash("password"); !errors.Is(err, scheme.ErrEntropyFailure) || !strings.Contains(err.Error(), "rng failure") {
+			t.Errorf("%v: expected entropy failure, got %q, %v", s, h, err)
+		}
+
+		// A source which runs dry must not produce a partially random salt.
+		c.Rand = iotest.HalfReader(strings.NewReader("abcd"))
+		if h, err := c.Hash("password"); !errors.Is(err, scheme.ErrEntropyFailure) {
+			t.Errorf("%v: expected entropy failure for short read, got %q, %v", s, h, err)
+		}
+	}
+
+	c := Context{Schemes: []scheme.Scheme{phpass.Crypter}, Rand: constReader(42)}
+	if _, err := c.Hash("password"); err != scheme.ErrVerifyOnly {
+		t.Errorf("expected ErrVerifyOnly, got %v", err)
+	}
+}
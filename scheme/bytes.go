@@ -0,0 +1,21 @@
+package scheme
+
+// BytesScheme is implemented by schemes which accept passwords as
+// byte slices, so that callers can wipe passwords after use.
+// Implementations do not retain the password and zero their own
+// copies and intermediate buffers derived from it.
+type BytesScheme interface {
+	// HashBytes is like Hash, but takes the password as a byte slice.
+	HashBytes(password []byte) (string, error)
+
+	// VerifyBytes is like Verify, but takes the password as a byte slice.
+	VerifyBytes(password []byte, hash string) error
+}
+
+// Zero overwrites b with zeros.
+// It is used to wipe passwords and buffers derived from them.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,25 @@
+package scheme
+
+// UpdateReason describes why a hash needs an update.
+type UpdateReason string
+
+const (
+	ReasonNotPreferred UpdateReason = "not-preferred" // The hash was verified by a scheme other than the preferred one.
+	ReasonCost         UpdateReason = "cost"          // The cost parameters are below policy.
+	ReasonSalt         UpdateReason = "salt"          // The salt is shorter than policy.
+	ReasonVariant      UpdateReason = "variant"       // The hash uses a deprecated variant or format version.
+	ReasonTruncated    UpdateReason = "truncated"     // The password only matched after being truncated.
+	ReasonNormalized   UpdateReason = "normalization" // The password only matched without normalization.
+	ReasonDeprecated   UpdateReason = "deprecated"    // The scheme requires an update without giving a reason.
+	ReasonUnbound      UpdateReason = "unbound"       // The hash is not bound to its owner.
+)
+
+// Describer is implemented by schemes which can describe their hashes in detail.
+type Describer interface {
+	// Params returns the parameters encoded in a stub or hash, keyed by name.
+	Params(stub string) (map[string]string, error)
+
+	// UpdateReasons returns the reasons why the stub needs an update.
+	// It returns nil exactly when NeedsUpdate returns false.
+	UpdateReasons(stub string) []UpdateReason
+}
@@ -0,0 +1,9 @@
+package scheme
+
+// Disabler is implemented by schemes whose hashes mark disabled accounts.
+// Such hashes never verify, so a Context never reports them as needing
+// an update, even though the scheme is not the preferred one.
+type Disabler interface {
+	// Disabled reports whether stub marks a disabled account.
+	Disabled(stub string) bool
+}
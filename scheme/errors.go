@@ -0,0 +1,68 @@
+package scheme
+
+import (
+	"errors"
+	"strings"
+)
+
+// Kinds of errors returned by schemes.
+// Use errors.Is to test for them and errors.As
+// with an *Error to find the scheme and field involved.
+var (
+	// The hash or stub is corrupted or not in the expected format.
+	ErrMalformedHash = errors.New("malformed hash")
+	// The hash is well-formed, but uses a parameter value
+	// or variant which the scheme does not support.
+	ErrUnsupportedParameter = errors.New("unsupported hash parameter")
+	// The hash is valid, but the password does not match it.
+	ErrPasswordMismatch = errors.New("invalid password")
+	// The input was refused by a configured policy,
+	// such as a limit on cost or password length.
+	ErrPolicyRejected = errors.New("rejected by policy")
+	// The entropy source failed, so no salt could be generated.
+	ErrEntropyFailure = errors.New("entropy source failed")
+)
+
+// Error describes a hash, stub or password rejected by a scheme.
+type Error struct {
+	Scheme string // Name of the scheme, such as "bcrypt".
+	Field  string // Offending field, such as "salt" or "rounds". May be empty.
+	Kind   error  // One of the error kinds above, such as ErrMalformedHash.
+	Err    error  // Underlying cause. May be nil.
+}
+
+// Malformed returns an Error of kind ErrMalformedHash.
+func Malformed(scheme, field string, cause error) *Error {
+	return &Error{Scheme: scheme, Field: field, Kind: ErrMalformedHash, Err: cause}
+}
+
+// Unsupported returns an Error of kind ErrUnsupportedParameter.
+func Unsupported(scheme, field string, cause error) *Error {
+	return &Error{Scheme: scheme, Field: field, Kind: ErrUnsupportedParameter, Err: cause}
+}
+
+// Rejected returns an Error of kind ErrPolicyRejected.
+func Rejected(scheme, field string, cause error) *Error {
+	return &Error{Scheme: scheme, Field: field, Kind: ErrPolicyRejected, Err: cause}
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if prefix := strings.TrimSpace(e.Scheme + " " + e.Field); prefix != "" {
+		msg = prefix + ": " + msg
+	}
+
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Err}
+}
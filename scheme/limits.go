@@ -0,0 +1,106 @@
+package scheme
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Limits are ceilings on the resources needed to verify a single hash.
+// They protect against denial of service through hashes with excessive
+// parameters, such as hashes planted by an import.
+// A zero field means no limit.
+type Limits struct {
+	// MaxRounds bounds the iteration count of PBKDF2, sha-crypt and GRUB hashes.
+	MaxRounds int `json:"max_rounds,omitempty"`
+	// MaxLogRounds bounds the base 2 logarithm of the iteration count
+	// of bcrypt, bcrypt-sha256 and phpass hashes, i.e. the bcrypt cost.
+	MaxLogRounds int `json:"max_log_rounds,omitempty"`
+	// MaxMemory bounds the memory in bytes used by scrypt and argon2 hashes.
+	MaxMemory uint64 `json:"max_memory,omitempty"`
+	// MaxPasses bounds the argon2 time parameter and the scrypt
+	// parallelism parameter, which multiply the work of these schemes.
+	MaxPasses int `json:"max_passes,omitempty"`
+}
+
+var defaultLimits = Limits{
+	MaxRounds:    2000000,
+	MaxLogRounds: 16,
+	MaxMemory:    256 << 20,
+	MaxPasses:    16,
+}
+
+// DefaultLimits returns the ceilings enforced by the schemes in this module
+// unless they are given other limits with WithLimits.
+// Schemes raise them as needed to verify the hashes they produce themselves.
+func DefaultLimits() Limits {
+	return defaultLimits
+}
+
+// Limiter is implemented by schemes which can check
+// a hash against resource ceilings without verifying it.
+type Limiter interface {
+	// CheckLimits returns an error of kind ErrPolicyRejected if verifying
+	// the hash would exceed limits, or the error encountered parsing it.
+	CheckLimits(stub string, limits Limits) error
+}
+
+// LimitSetter is implemented by schemes whose own ceilings can be replaced.
+type LimitSetter interface {
+	// WithLimits returns a copy of the scheme enforcing limits
+	// instead of DefaultLimits when verifying a hash.
+	// The scheme still raises them as needed
+	// to verify the hashes it produces itself.
+	WithLimits(limits Limits) Scheme
+}
+
+// Returned by WithLimits for schemes which do not implement LimitSetter.
+var ErrLimitsUnsupported error = Unsupported("", "limits", errors.New("scheme does not support custom limits"))
+
+// WithLimits returns s enforcing limits,
+// or ErrLimitsUnsupported if s does not implement LimitSetter.
+func WithLimits(s Scheme, limits Limits) (Scheme, error) {
+	ls, ok := s.(LimitSetter)
+	if !ok {
+		return nil, ErrLimitsUnsupported
+	}
+
+	return ls.WithLimits(limits), nil
+}
+
+// CheckRounds checks an iteration count against MaxRounds.
+func (l Limits) CheckRounds(scheme, field string, rounds int) error {
+	if l.MaxRounds != 0 && rounds > l.MaxRounds {
+		return Rejected(scheme, field, nil)
+	}
+
+	return nil
+}
+
+// CheckLogRounds checks a logarithmic cost against MaxLogRounds.
+func (l Limits) CheckLogRounds(scheme, field string, logRounds int) error {
+	if l.MaxLogRounds != 0 && logRounds > l.MaxLogRounds {
+		return Rejected(scheme, field, nil)
+	}
+
+	return nil
+}
+
+// CheckMemory checks a memory requirement of count blocks
+// of size bytes each against MaxMemory.
+func (l Limits) CheckMemory(scheme, field string, count, size uint64) error {
+	hi, memory := bits.Mul64(count, size)
+	if l.MaxMemory != 0 && (hi != 0 || memory > l.MaxMemory) {
+		return Rejected(scheme, field, nil)
+	}
+
+	return nil
+}
+
+// CheckPasses checks a number of passes against MaxPasses.
+func (l Limits) CheckPasses(scheme, field string, passes int) error {
+	if l.MaxPasses != 0 && passes > l.MaxPasses {
+		return Rejected(scheme, field, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package scheme
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// RandSetter is implemented by schemes whose source of
+// randomness for salts can be replaced, for example by
+// a deterministic reader in tests or an HSM-backed reader.
+type RandSetter interface {
+	// WithRand returns a copy of the scheme reading salts from r.
+	// If r is nil, crypto/rand.Reader is used.
+	// r must be safe for concurrent use if the scheme is.
+	WithRand(r io.Reader) Scheme
+}
+
+// Returned by WithRand for schemes which do not implement RandSetter.
+var ErrRandUnsupported error = Unsupported("", "rand", errors.New("scheme does not support a custom entropy source"))
+
+// WithRand returns s reading salts from r,
+// or ErrRandUnsupported if s does not implement RandSetter.
+func WithRand(s Scheme, r io.Reader) (Scheme, error) {
+	rs, ok := s.(RandSetter)
+	if !ok {
+		return nil, ErrRandUnsupported
+	}
+
+	return rs.WithRand(r), nil
+}
+
+// ReadSalt fills salt from r, or from crypto/rand.Reader if r is nil.
+// Errors and short reads are returned as errors of kind ErrEntropyFailure,
+// so that a hash is never produced with a partially random salt.
+func ReadSalt(scheme string, r io.Reader, salt []byte) error {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	if _, err := io.ReadFull(r, salt); err != nil {
+		return &Error{Scheme: scheme, Field: "salt", Kind: ErrEntropyFailure, Err: err}
+	}
+
+	return nil
+}
@@ -5,7 +5,10 @@ package scheme
 import "errors"
 
 var (
-	ErrInvalidPassword   = errors.New("invalid password")
+	// Returned when the password does not match the hash.
+	// It is the same value as ErrPasswordMismatch.
+	ErrInvalidPassword = ErrPasswordMismatch
+	// Returned when no scheme supports the hash.
 	ErrUnsupportedScheme = errors.New("unsupported scheme")
 )
 
@@ -0,0 +1,14 @@
+package scheme
+
+// Truncater is implemented by schemes which may ignore
+// part of long passwords, such as bcrypt.
+// Contexts use it to upgrade hashes which only matched
+// because the password was truncated.
+type Truncater interface {
+	// VerifyTruncated is like VerifyBytes, but additionally reports
+	// whether the password only matched after being truncated.
+	VerifyTruncated(password []byte, hash string) (truncated bool, err error)
+
+	// Truncates reports whether Hash would truncate the password.
+	Truncates(password []byte) bool
+}
@@ -0,0 +1,63 @@
+package scheme
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Returned by Hash of schemes which can only verify existing hashes.
+var ErrVerifyOnly = errors.New("scheme can only verify passwords")
+
+// VerifyOnly wraps a Scheme so that it verifies existing hashes,
+// but refuses to produce new ones.
+// It is intended for legacy formats which should only be migrated away from.
+func VerifyOnly(s Scheme) Scheme {
+	return verifyOnly{s}
+}
+
+type verifyOnly struct {
+	Scheme
+}
+
+func (s verifyOnly) Hash(password string) (string, error) {
+	return "", ErrVerifyOnly
+}
+
+func (s verifyOnly) HashBytes(password []byte) (string, error) {
+	return "", ErrVerifyOnly
+}
+
+func (s verifyOnly) VerifyBytes(password []byte, hash string) error {
+	if b, ok := s.Scheme.(BytesScheme); ok {
+		return b.VerifyBytes(password, hash)
+	}
+
+	return s.Scheme.Verify(string(password), hash)
+}
+
+func (s verifyOnly) CheckLimits(stub string, limits Limits) error {
+	if l, ok := s.Scheme.(Limiter); ok {
+		return l.CheckLimits(stub, limits)
+	}
+
+	return nil
+}
+
+// WithRand returns the scheme itself, as it never generates salts.
+func (s verifyOnly) WithRand(r io.Reader) Scheme {
+	return s
+}
+
+// WithLimits passes limits to the wrapped scheme, if it supports them.
+func (s verifyOnly) WithLimits(limits Limits) Scheme {
+	if ls, ok := s.Scheme.(LimitSetter); ok {
+		return verifyOnly{ls.WithLimits(limits)}
+	}
+
+	return s
+}
+
+func (s verifyOnly) String() string {
+	return fmt.Sprintf("%v", s.Scheme)
+}
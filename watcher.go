@@ -0,0 +1,160 @@
+package pass
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher keeps a Context in sync with a policy file (see Policy),
+// so that costs can be tuned and schemes deprecated without a redeploy.
+// The file is re-read periodically or when a signal is received.
+// An invalid file is reported and the last good context is kept.
+type Watcher struct {
+	path       string
+	interval   time.Duration
+	signals    []os.Signal
+	onError    func(error)
+	setDefault bool
+
+	ctx  atomic.Pointer[Context]
+	mu   sync.Mutex // Serializes reloads.
+	last []byte
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// WatcherOption configures a Watcher.
+type WatcherOption func(*Watcher)
+
+// WatchInterval re-reads the policy file every interval.
+func WatchInterval(interval time.Duration) WatcherOption {
+	return func(w *Watcher) { w.interval = interval }
+}
+
+// WatchSignals re-reads the policy file when one of the signals
+// is received, typically syscall.SIGHUP.
+func WatchSignals(signals ...os.Signal) WatcherOption {
+	return func(w *Watcher) { w.signals = append(w.signals, signals...) }
+}
+
+// WatchErrors sets a function called with the errors of background reloads.
+// The previous context remains active when a reload fails.
+func WatchErrors(onError func(error)) WatcherOption {
+	return func(w *Watcher) { w.onError = onError }
+}
+
+// WatchSetDefault installs each loaded context with SetDefault,
+// so that the package-level functions use the policy file.
+func WatchSetDefault() WatcherOption {
+	return func(w *Watcher) { w.setDefault = true }
+}
+
+// NewWatcher loads the policy file at path and, if an interval or signals
+// are given, starts watching it in the background until Close is called.
+// An error is returned if the file cannot be loaded initially.
+func NewWatcher(path string, opts ...WatcherOption) (*Watcher, error) {
+	w := &Watcher{
+		path: path,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	if w.interval <= 0 && len(w.signals) == 0 {
+		close(w.done)
+		return w, nil
+	}
+
+	go w.watch()
+
+	return w, nil
+}
+
+// Context returns the context of the last valid policy.
+// The returned context must not be modified.
+func (w *Watcher) Context() *Context {
+	return w.ctx.Load()
+}
+
+// Reload re-reads the policy file and, if it is valid,
+// atomically replaces the active context.
+// Otherwise it returns the error and keeps the active context.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+
+	if w.ctx.Load() != nil && bytes.Equal(data, w.last) {
+		return nil
+	}
+
+	ctx, err := ParsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	w.last = data
+	w.ctx.Store(ctx)
+	if w.setDefault {
+		SetDefault(ctx)
+	}
+
+	return nil
+}
+
+// Close stops watching the policy file.
+// The last loaded context remains valid.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+
+	return nil
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	var sig chan os.Signal
+	if len(w.signals) != 0 {
+		sig = make(chan os.Signal, 1)
+		signal.Notify(sig, w.signals...)
+		defer signal.Stop(sig)
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-tick:
+		case <-sig:
+		}
+
+		if err := w.Reload(); err != nil && w.onError != nil {
+			w.onError(err)
+		}
+	}
+}
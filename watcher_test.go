@@ -0,0 +1,91 @@
+package pass
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	write := func(policy string) {
+		if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if _, err := NewWatcher(path); err == nil {
+		t.Errorf("missing policy file accepted")
+	}
+
+	write(`{"schemes": [{"name": "sha256-crypt", "params": {"rounds": 1000}}]}`)
+
+	errs := make(chan error, 10)
+	w, err := NewWatcher(path, WatchInterval(time.Millisecond), WatchErrors(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer w.Close()
+
+	old := w.Context()
+	hash, err := old.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	write(`{"schemes": [{"name": "sha512-crypt", "params": {"rounds": 1000}}, {"name": "sha256-crypt"}]}`)
+	waitFor(t, func() bool { return w.Context() != old })
+
+	if newHash, err := w.Context().Verify("password", hash); err != nil || newHash == "" {
+		t.Errorf("expected upgrade after reload: %q, %v", newHash, err)
+	}
+
+	good := w.Context()
+	write(`{"schemes": [{"name": "sha512-crypt", "params": {"rounds": 1}}]}`)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Errorf("nil reload error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("invalid policy not reported")
+	}
+
+	if w.Context() != good {
+		t.Errorf("invalid policy replaced the last good context")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("err: %v", err)
+	}
+}
+
+func TestWatcherSetDefault(t *testing.T) {
+	defer SetDefault(nil)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"schemes": [{"name": "sha256-crypt", "params": {"rounds": 1234}}]}`), 0o600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	w, err := NewWatcher(path, WatchSetDefault())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer w.Close()
+
+	if Default() != w.Context() || fmt.Sprint(Default().Schemes[0]) != "sha256-crypt(1234,16)" {
+		t.Errorf("policy not installed as the default context")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out")
+		}
+	}
+}